@@ -0,0 +1,280 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// Classify an API change as breaking or backward-compatible, using the type
+// information of the package at both revisions
+func classifyChange(c *APIChange, oldPkg, newPkg *types.Package) {
+	// Only changes to the exported API can break users
+	for _, part := range strings.Split(c.Name, ".") {
+		if !ast.IsExported(part) {
+			return
+		}
+	}
+
+	switch c.Change {
+	case ChangeAdded:
+		return
+	case ChangeRemoved:
+		c.Breaking, c.Reason = true, "removed"
+		return
+	}
+
+	oldObj := lookupObject(oldPkg, c.Name)
+	newObj := lookupObject(newPkg, c.Name)
+	if oldObj == nil || newObj == nil || hasInvalidType(oldObj) || hasInvalidType(newObj) {
+		// Without type information, any change to an exported declaration is suspect
+		c.Breaking, c.Reason = true, "declaration changed"
+		return
+	}
+
+	var breaking, compatible []string
+	switch o := oldObj.(type) {
+	case *types.Func:
+		if n, ok := newObj.(*types.Func); ok {
+			breaking, compatible = compareFuncs(o, n)
+		} else {
+			breaking = append(breaking, "kind changed")
+		}
+	case *types.Const:
+		if n, ok := newObj.(*types.Const); !ok {
+			breaking = append(breaking, "kind changed")
+		} else if t1, t2 := typeString(o.Type(), oldPkg), typeString(n.Type(), newPkg); t1 != t2 {
+			breaking = append(breaking, fmt.Sprintf("type changed from %s to %s", t1, t2))
+		} else if o.Val().ExactString() != n.Val().ExactString() {
+			compatible = append(compatible, "value changed")
+		}
+	case *types.Var:
+		if n, ok := newObj.(*types.Var); !ok {
+			breaking = append(breaking, "kind changed")
+		} else if t1, t2 := typeString(o.Type(), oldPkg), typeString(n.Type(), newPkg); t1 != t2 {
+			breaking = append(breaking, fmt.Sprintf("type changed from %s to %s", t1, t2))
+		} else {
+			compatible = append(compatible, "value changed")
+		}
+	case *types.TypeName:
+		if n, ok := newObj.(*types.TypeName); ok {
+			breaking, compatible = compareTypeNames(o, n)
+		} else {
+			breaking = append(breaking, "kind changed")
+		}
+	}
+
+	if len(breaking) > 0 {
+		c.Breaking, c.Reason = true, strings.Join(breaking, "; ")
+	} else {
+		c.Reason = strings.Join(compatible, "; ")
+	}
+}
+
+// Find a package-level object, or a method when name is "Type.Method"
+func lookupObject(pkg *types.Package, name string) types.Object {
+	if pkg == nil {
+		return nil
+	}
+	typeName, method, isMethod := strings.Cut(name, ".")
+	obj := pkg.Scope().Lookup(typeName)
+	if !isMethod || obj == nil {
+		return obj
+	}
+	named, ok := obj.Type().(*types.Named)
+	if !ok {
+		return nil
+	}
+	for i := 0; i < named.NumMethods(); i++ {
+		if m := named.Method(i); m.Name() == method {
+			return m
+		}
+	}
+	return nil
+}
+
+// Helper function to check if the type of an object could not be resolved,
+// for instance because an import is not available
+func hasInvalidType(obj types.Object) bool {
+	return strings.Contains(types.ObjectString(obj, nil), "invalid type")
+}
+
+// Helper function to format a type relative to its own package
+func typeString(t types.Type, pkg *types.Package) string {
+	return types.TypeString(t, types.RelativeTo(pkg))
+}
+
+// Compare two versions of a function or method
+func compareFuncs(o, n *types.Func) (breaking, compatible []string) {
+	oldSig, newSig := o.Type().(*types.Signature), n.Type().(*types.Signature)
+
+	// Moving a method from a pointer to a value receiver only grows the method sets
+	if oldSig.Recv() != nil && newSig.Recv() != nil {
+		_, oldPtr := oldSig.Recv().Type().(*types.Pointer)
+		_, newPtr := newSig.Recv().Type().(*types.Pointer)
+		switch {
+		case !oldPtr && newPtr:
+			breaking = append(breaking, "receiver changed from value to pointer")
+		case oldPtr && !newPtr:
+			compatible = append(compatible, "receiver changed from pointer to value")
+		}
+	}
+
+	if s1, s2 := signatureString(oldSig, o.Pkg()), signatureString(newSig, n.Pkg()); s1 != s2 {
+		breaking = append(breaking, fmt.Sprintf("signature changed from %s to %s", s1, s2))
+	}
+	return breaking, compatible
+}
+
+// Format the type parameters, parameter and result types of a signature,
+// ignoring parameter names and the receiver
+func signatureString(sig *types.Signature, pkg *types.Package) string {
+	var buf strings.Builder
+	buf.WriteString("func")
+	if tparams := sig.TypeParams(); tparams.Len() > 0 {
+		list := make([]string, 0, tparams.Len())
+		for i := 0; i < tparams.Len(); i++ {
+			tp := tparams.At(i)
+			list = append(list, tp.Obj().Name()+" "+typeString(tp.Constraint(), pkg))
+		}
+		buf.WriteString("[" + strings.Join(list, ", ") + "]")
+	}
+	buf.WriteString("(" + tupleString(sig.Params(), sig.Variadic(), pkg) + ")")
+	if results := sig.Results(); results.Len() == 1 {
+		buf.WriteString(" " + tupleString(results, false, pkg))
+	} else if results.Len() > 1 {
+		buf.WriteString(" (" + tupleString(results, false, pkg) + ")")
+	}
+	return buf.String()
+}
+
+// Helper function to format the types of a tuple
+func tupleString(tuple *types.Tuple, variadic bool, pkg *types.Package) string {
+	list := make([]string, 0, tuple.Len())
+	for i := 0; i < tuple.Len(); i++ {
+		t := tuple.At(i).Type()
+		if variadic && i == tuple.Len()-1 {
+			if s, ok := t.(*types.Slice); ok {
+				list = append(list, "..."+typeString(s.Elem(), pkg))
+				continue
+			}
+		}
+		list = append(list, typeString(t, pkg))
+	}
+	return strings.Join(list, ", ")
+}
+
+// Compare two versions of a type declaration
+func compareTypeNames(o, n *types.TypeName) (breaking, compatible []string) {
+	oldPkg, newPkg := o.Pkg(), n.Pkg()
+	if o.IsAlias() != n.IsAlias() {
+		return []string{"changed between alias and defined type"}, nil
+	}
+	if oldNamed, ok := o.Type().(*types.Named); ok {
+		if newNamed, ok := n.Type().(*types.Named); ok && typeParamsString(oldNamed, oldPkg) != typeParamsString(newNamed, newPkg) {
+			breaking = append(breaking, "type parameters changed")
+		}
+	}
+
+	oldUnder, newUnder := o.Type().Underlying(), n.Type().Underlying()
+	switch ou := oldUnder.(type) {
+	case *types.Struct:
+		nu, ok := newUnder.(*types.Struct)
+		if !ok {
+			break
+		}
+		oldFields := structFields(ou, oldPkg)
+		newFields := structFields(nu, newPkg)
+		for _, name := range sortedKeys(oldFields) {
+			newType, ok := newFields[name]
+			switch {
+			case !ok:
+				breaking = append(breaking, "field "+name+" removed")
+			case newType != oldFields[name]:
+				breaking = append(breaking, fmt.Sprintf("field %s changed from %s to %s", name, oldFields[name], newType))
+			}
+		}
+		for _, name := range sortedKeys(newFields) {
+			if _, ok := oldFields[name]; !ok {
+				compatible = append(compatible, "field "+name+" added")
+			}
+		}
+		return breaking, compatible
+	case *types.Interface:
+		nu, ok := newUnder.(*types.Interface)
+		if !ok {
+			break
+		}
+		oldMethods := interfaceMethods(ou, oldPkg)
+		newMethods := interfaceMethods(nu, newPkg)
+		for _, name := range sortedKeys(oldMethods) {
+			newSig, ok := newMethods[name]
+			switch {
+			case !ok:
+				breaking = append(breaking, "method "+name+" removed from interface")
+			case newSig != oldMethods[name]:
+				breaking = append(breaking, "method "+name+" changed")
+			}
+		}
+		for _, name := range sortedKeys(newMethods) {
+			if _, ok := oldMethods[name]; !ok {
+				breaking = append(breaking, "method "+name+" added to interface")
+			}
+		}
+		if !ou.IsMethodSet() || !nu.IsMethodSet() {
+			// Constraint interfaces: compare their type sets as written
+			if t1, t2 := typeString(ou, oldPkg), typeString(nu, newPkg); t1 != t2 {
+				breaking = append(breaking, fmt.Sprintf("constraint changed from %s to %s", t1, t2))
+			}
+		}
+		return breaking, compatible
+	}
+
+	if t1, t2 := typeString(oldUnder, oldPkg), typeString(newUnder, newPkg); t1 != t2 {
+		breaking = append(breaking, fmt.Sprintf("underlying type changed from %s to %s", t1, t2))
+	}
+	return breaking, compatible
+}
+
+// Helper function to format the type parameters of a named type
+func typeParamsString(named *types.Named, pkg *types.Package) string {
+	tparams := named.TypeParams()
+	list := make([]string, 0, tparams.Len())
+	for i := 0; i < tparams.Len(); i++ {
+		list = append(list, typeString(tparams.At(i).Constraint(), pkg))
+	}
+	return strings.Join(list, ", ")
+}
+
+// Collect the exported fields of a struct with their types
+func structFields(s *types.Struct, pkg *types.Package) map[string]string {
+	fields := make(map[string]string)
+	for i := 0; i < s.NumFields(); i++ {
+		if f := s.Field(i); f.Exported() {
+			fields[f.Name()] = typeString(f.Type(), pkg)
+		}
+	}
+	return fields
+}
+
+// Collect all methods of an interface, including embedded ones, with their signatures
+func interfaceMethods(iface *types.Interface, pkg *types.Package) map[string]string {
+	methods := make(map[string]string)
+	for i := 0; i < iface.NumMethods(); i++ {
+		m := iface.Method(i)
+		methods[m.Name()] = signatureString(m.Type().(*types.Signature), pkg)
+	}
+	return methods
+}
+
+// Helper function to get the sorted keys of a map
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/build/constraint"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	buildTags    string
+	buildGOOS    string
+	buildGOARCH  string
+	allPlatforms bool
+)
+
+// Helper function to check if files are selected by build constraints:
+// always, for the host platform by default, except with -all-platforms
+func buildFilterEnabled() bool {
+	return !allPlatforms
+}
+
+// Get the build context of the selected platform and tags. Cgo is enabled
+// like the go command does: for the host platform, unless cross-compiling.
+func buildContext() build.Context {
+	ctxt := build.Default
+	if buildGOOS != "" {
+		ctxt.GOOS = buildGOOS
+	}
+	if buildGOARCH != "" {
+		ctxt.GOARCH = buildGOARCH
+	}
+	if ctxt.GOOS != build.Default.GOOS || ctxt.GOARCH != build.Default.GOARCH {
+		ctxt.CgoEnabled = os.Getenv("CGO_ENABLED") == "1"
+	}
+	ctxt.BuildTags = nil
+	for _, tag := range strings.Split(buildTags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			ctxt.BuildTags = append(ctxt.BuildTags, tag)
+		}
+	}
+	return ctxt
+}
+
+// Helper function to match a file with a build context, given the header
+// of the file to read in place of its content. Files of any extension are
+// matched as Go files.
+func matchFile(ctxt build.Context, filename, header string) bool {
+	ctxt.OpenFile = func(string) (io.ReadCloser, error) {
+		return io.NopCloser(strings.NewReader(header)), nil
+	}
+	name := filepath.Base(filename)
+	name = strings.TrimSuffix(name, filepath.Ext(name)) + ".go"
+	ok, err := ctxt.MatchFile(filepath.Dir(filename), name)
+	return ok || err != nil
+}
+
+// Get the build constraint of a file: its //go:build line (or legacy
+// // +build lines) combined with the constraints implied by its name.
+// Returns nil for files without constraints.
+func fileConstraint(filename string, f *ast.File) constraint.Expr {
+	expr := headerConstraint(f)
+	if nameExpr := nameConstraint(filename); nameExpr != nil {
+		if expr == nil {
+			expr = nameExpr
+		} else {
+			expr = &constraint.AndExpr{X: expr, Y: nameExpr}
+		}
+	}
+	return expr
+}
+
+// Parse the build constraint lines preceding the package clause
+func headerConstraint(f *ast.File) constraint.Expr {
+	var goBuild constraint.Expr
+	var plusBuild []constraint.Expr
+	for _, group := range f.Comments {
+		if group.Pos() >= f.Package {
+			break
+		}
+		for _, comment := range group.List {
+			switch {
+			case constraint.IsGoBuild(comment.Text):
+				if expr, err := constraint.Parse(comment.Text); err == nil && goBuild == nil {
+					goBuild = expr
+				}
+			case constraint.IsPlusBuild(comment.Text):
+				if expr, err := constraint.Parse(comment.Text); err == nil {
+					plusBuild = append(plusBuild, expr)
+				}
+			}
+		}
+	}
+
+	// //go:build lines take precedence over // +build lines
+	if goBuild != nil {
+		return goBuild
+	}
+	var expr constraint.Expr
+	for _, x := range plusBuild {
+		if expr == nil {
+			expr = x
+		} else {
+			expr = &constraint.AndExpr{X: expr, Y: x}
+		}
+	}
+	return expr
+}
+
+// Get the constraint implied by a file name, e.g. "windows && amd64" for
+// foo_windows_amd64.go. The part before the first underscore is never a
+// constraint, and _test is ignored.
+func nameConstraint(filename string) constraint.Expr {
+	name := filepath.Base(filename)
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	i := strings.Index(name, "_")
+	if i <= 0 || strings.HasPrefix(name, ".") {
+		return nil
+	}
+	parts := strings.Split(strings.TrimSuffix(name[i:], "_test"), "_")
+	n := len(parts)
+
+	// Match the name for a platform that does not exist: it does not match
+	// when its last part is a known GOOS or GOARCH, and still does not with
+	// that part as a tag when it is the GOARCH of a _GOOS_GOARCH suffix
+	none := build.Context{GOOS: "none", GOARCH: "none", Compiler: "gc"}
+	matches := func(tags ...string) bool {
+		none.BuildTags = tags
+		return matchFile(none, filename, "package p\n")
+	}
+	switch {
+	case matches():
+		return nil
+	case n >= 2 && !matches(parts[n-1]):
+		return &constraint.AndExpr{
+			X: &constraint.TagExpr{Tag: parts[n-2]},
+			Y: &constraint.TagExpr{Tag: parts[n-1]},
+		}
+	}
+	return &constraint.TagExpr{Tag: parts[n-1]}
+}
+
+// Check whether a file is part of the build for the selected platform and
+// tags: its constraints are evaluated like the go command does, including
+// the cgo tag for files importing "C". Always true with -all-platforms.
+func buildMatches(filename string, f *ast.File) bool {
+	if !buildFilterEnabled() {
+		return true
+	}
+	ctxt := buildContext()
+	for _, imp := range f.Imports {
+		// Cgo files are matched, but only built with cgo enabled
+		if imp.Path.Value == `"C"` && !ctxt.CgoEnabled {
+			return false
+		}
+	}
+	header := "package p\n"
+	if expr := headerConstraint(f); expr != nil {
+		header = fmt.Sprintf("//go:build %s\n\n%s", expr, header)
+	}
+	return matchFile(ctxt, filename, header)
+}
+
+// Read the header of a file and check whether it is part of the build.
+// Unreadable files are kept so that their errors are reported.
+func fileMatchesBuild(filename string) bool {
+	if !buildFilterEnabled() {
+		return true
+	}
+	f, err := parser.ParseFile(token.NewFileSet(), filename, nil, parser.ImportsOnly|parser.ParseComments)
+	if f == nil || err != nil {
+		return true
+	}
+	return buildMatches(filename, f)
+}
+
+// Get the build flags and environment passed to go/packages
+func packagesBuildConfig() (flags, env []string) {
+	if buildTags != "" {
+		flags = append(flags, "-tags="+buildTags)
+	}
+	if buildGOOS != "" {
+		env = append(env, "GOOS="+buildGOOS)
+	}
+	if buildGOARCH != "" {
+		env = append(env, "GOARCH="+buildGOARCH)
+	}
+	return flags, env
+}
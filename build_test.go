@@ -0,0 +1,191 @@
+package main
+
+import (
+	"go/build"
+	"go/token"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestBuildConstraints(t *testing.T) {
+	files := map[string]string{
+		"common.go":             "package a\nfunc Common() {}\n",
+		"os_linux.go":           "package a\nfunc Linux() {}\n",
+		"os_windows.go":         "package a\nfunc Windows() {}\n",
+		"os_windows_arm64.go":   "package a\nfunc WindowsARM() {}\n",
+		"linux.go":              "package a\nfunc NotConstrained() {}\n",
+		"integration.go":        "//go:build integration && !windows\n\npackage a\nfunc Integration() {}\n",
+		"legacy.go":             "// +build !windows\n\npackage a\nfunc Legacy() {}\n",
+		"unix.go":               "// Copyright notice.\n\n//go:build unix\n\npackage a\nfunc Unix() {}\n",
+		"os_linux_test_util.go": "package a\nfunc Util() {}\n",
+	}
+
+	tests := []struct {
+		name         string
+		tags         string
+		goos         string
+		goarch       string
+		allPlatforms bool
+		want         []string
+	}{
+		{
+			name:   "linux",
+			goos:   "linux",
+			goarch: "amd64",
+			want: []string{
+				"common.go: func Common()",
+				"legacy.go: func Legacy()",
+				"linux.go: func NotConstrained()",
+				"os_linux.go: func Linux()",
+				"os_linux_test_util.go: func Util()",
+				"unix.go: func Unix()",
+			},
+		},
+		{
+			name:   "windows arm64",
+			goos:   "windows",
+			goarch: "arm64",
+			want: []string{
+				"common.go: func Common()",
+				"linux.go: func NotConstrained()",
+				"os_linux_test_util.go: func Util()",
+				"os_windows.go: func Windows()",
+				"os_windows_arm64.go: func WindowsARM()",
+			},
+		},
+		{
+			name:   "tags",
+			tags:   "integration",
+			goos:   "darwin",
+			goarch: "arm64",
+			want: []string{
+				"common.go: func Common()",
+				"integration.go: func Integration()",
+				"legacy.go: func Legacy()",
+				"linux.go: func NotConstrained()",
+				"os_linux_test_util.go: func Util()",
+				"unix.go: func Unix()",
+			},
+		},
+		{
+			name:         "all platforms",
+			goos:         "linux",
+			allPlatforms: true,
+			want: []string{
+				"common.go: func Common()",
+				"integration.go: func Integration() // build: integration && !windows",
+				"legacy.go: func Legacy() // build: !windows",
+				"linux.go: func NotConstrained()",
+				"os_linux.go: func Linux() // build: linux",
+				"os_linux_test_util.go: func Util()",
+				"os_windows.go: func Windows() // build: windows",
+				"os_windows_arm64.go: func WindowsARM() // build: windows && arm64",
+				"unix.go: func Unix() // build: unix",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			workDir = tmpDir
+			writeTestFiles(t, tmpDir, files)
+
+			includePrivate = false
+			fileExtensions = ".go"
+			excludeSuffixes = "_test.go"
+			buildTags, buildGOOS, buildGOARCH, allPlatforms = tt.tags, tt.goos, tt.goarch, tt.allPlatforms
+			t.Cleanup(func() {
+				buildTags, buildGOOS, buildGOARCH, allPlatforms = "", "", "", false
+				workDir = ""
+			})
+
+			output := captureOutput(func() {
+				if err := processPaths([]string{tmpDir}, token.NewFileSet()); err != nil {
+					t.Fatal(err)
+				}
+			})
+			if got := strings.TrimSpace(output); got != strings.Join(tt.want, "\n") {
+				t.Errorf("got:\n%s\nwant:\n%s", got, strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestBuildConstraintsHost(t *testing.T) {
+	goos := build.Default.GOOS
+	other := "plan9"
+	if goos == other {
+		other = "linux"
+	}
+	tmpDir := t.TempDir()
+	writeTestFiles(t, tmpDir, map[string]string{
+		"host.go":             "//go:build " + goos + "\n\npackage a\nfunc Host() {}\n",
+		"other.go":            "//go:build !" + goos + "\n\npackage a\nfunc Other() {}\n",
+		"os_" + goos + ".go":  "package a\nfunc HostName() {}\n",
+		"os_" + other + ".go": "package a\nfunc OtherName() {}\n",
+		"cgo.go":              "package a\nimport \"C\"\nfunc Cgo() {}\n",
+		"nocgo.go":            "//go:build !cgo\n\npackage a\nfunc NoCgo() {}\n",
+	})
+
+	includePrivate = false
+	fileExtensions = ".go"
+	excludeSuffixes = "_test.go"
+	workDir = tmpDir
+	t.Cleanup(func() { buildGOOS, workDir = "", "" })
+
+	list := func() string {
+		return captureOutput(func() {
+			if err := processPaths([]string{tmpDir}, token.NewFileSet()); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+
+	// Constraints are evaluated for the host platform without flags, and
+	// cgo files are built when cgo is enabled
+	want := []string{"host.go: func Host()", "os_" + goos + ".go: func HostName()"}
+	if build.Default.CgoEnabled {
+		want = append(want, "cgo.go: func Cgo()")
+	} else {
+		want = append(want, "nocgo.go: func NoCgo()")
+	}
+	sort.Strings(want)
+	if got := strings.TrimSpace(list()); got != strings.Join(want, "\n") {
+		t.Errorf("got:\n%s\nwant:\n%s", got, strings.Join(want, "\n"))
+	}
+
+	// Cgo is disabled when cross-compiling, unless CGO_ENABLED=1
+	buildGOOS = other
+	t.Setenv("CGO_ENABLED", "")
+	want = []string{"nocgo.go: func NoCgo()", "os_" + other + ".go: func OtherName()", "other.go: func Other()"}
+	if got := strings.TrimSpace(list()); got != strings.Join(want, "\n") {
+		t.Errorf("got:\n%s\nwant:\n%s", got, strings.Join(want, "\n"))
+	}
+}
+
+func TestNameConstraint(t *testing.T) {
+	tests := []struct {
+		filename string
+		want     string
+	}{
+		{"foo.go", ""},
+		{"linux.go", ""},
+		{"foo_linux.go", "linux"},
+		{"foo_amd64.go", "amd64"},
+		{"foo_windows_amd64.go", "windows && amd64"},
+		{"foo_linux_test.go", "linux"},
+		{"foo_bar.go", ""},
+		{"dir/foo_plan9.gno", "plan9"},
+	}
+	for _, tt := range tests {
+		got := ""
+		if expr := nameConstraint(tt.filename); expr != nil {
+			got = expr.String()
+		}
+		if got != tt.want {
+			t.Errorf("nameConstraint(%q) = %q, want %q", tt.filename, got, tt.want)
+		}
+	}
+}
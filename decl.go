@@ -0,0 +1,73 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+)
+
+// Declaration kinds
+const (
+	KindFunc   = "func"
+	KindMethod = "method"
+	KindType   = "type"
+	KindVar    = "var"
+	KindConst  = "const"
+)
+
+// Decl is a single top-level declaration extracted from a source file.
+// It is the model shared by every output format.
+type Decl struct {
+	Package   string `json:"package,omitempty"`
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+	Column    int    `json:"column"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Receiver  string `json:"receiver,omitempty"`
+	Signature string `json:"signature"`
+	Source    string `json:"source,omitempty"` // gofmt-formatted source, with -pretty
+	Exported  bool   `json:"exported"`
+	Doc       string `json:"doc,omitempty"`
+	Type      string `json:"type,omitempty"`
+	Value     string `json:"value,omitempty"`
+
+	// Fields of a struct type, with their tags
+	Fields []*Field `json:"fields,omitempty"`
+
+	// Enums: the type of the constants of an enum const group, and for an
+	// enum type, its members and whether it has a String() method
+	Enum     string   `json:"enum,omitempty"`
+	Members  []string `json:"members,omitempty"`
+	Stringer bool     `json:"stringer,omitempty"`
+
+	// Interfaces implemented by a type, by its pointer type only, and the
+	// types implementing an interface, with -implements
+	Implements        []string `json:"implements,omitempty"`
+	PointerImplements []string `json:"pointer_implements,omitempty"`
+	ImplementedBy     []string `json:"implemented_by,omitempty"`
+
+	// Underlying type of a defined type, when resolved by the type checker
+	Underlying string `json:"underlying,omitempty"`
+
+	// Build constraint of the file, with -all-platforms
+	Constraint string `json:"constraint,omitempty"`
+
+	// Test entry points with -tests: the kind of entry point, the identifier
+	// documented by an example, and exported declarations without tests
+	Test     string `json:"test,omitempty"`
+	Example  string `json:"example,omitempty"`
+	Untested bool   `json:"untested,omitempty"`
+
+	// Gno packages: "realm" or "pure", crossing functions callable as realm
+	// entry points, and package-level variables persisted by a realm
+	PackageKind string `json:"package_kind,omitempty"`
+	Crossing    bool   `json:"crossing,omitempty"`
+	Persistent  bool   `json:"persistent,omitempty"`
+
+	pos      token.Pos
+	typeKind string   // "struct" or "interface" for struct and interface types
+	results  []string // base type names of a function's results
+	funcDecl *ast.FuncDecl
+	obj      types.Object // type-checked object of a type, if any
+}
@@ -0,0 +1,389 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// Kinds of API changes
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeChanged = "changed"
+)
+
+// APIChange is a declaration added, removed or changed between two revisions
+type APIChange struct {
+	Package  string `json:"package"`
+	Kind     string `json:"kind"`
+	Name     string `json:"name"`
+	Change   string `json:"change"`
+	Old      string `json:"old,omitempty"`
+	New      string `json:"new,omitempty"`
+	Breaking bool   `json:"breaking"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// revision holds the declarations and type-checked packages of a git revision
+type revision struct {
+	decls []*Decl
+	pkgs  map[string]*types.Package // by package directory
+}
+
+// Compare the declarations of two git revisions: diff <base> <head> [paths...]
+func runDiff(args []string, fset *token.FileSet) error {
+	if len(args) < 2 {
+		return fmt.Errorf("usage: revbro diff [flags] <base> <head> [path...]")
+	}
+	base, head := args[0], args[1]
+
+	// Convert the paths to git pathspecs, ./... meaning the whole directory
+	var paths []string
+	for _, path := range args[2:] {
+		path = strings.TrimSuffix(strings.TrimSuffix(path, "/..."), "\\...")
+		if path == "" {
+			path = "."
+		}
+		paths = append(paths, path)
+	}
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	oldRev, err := loadRevision(base, paths, fset)
+	if err != nil {
+		return err
+	}
+	newRev, err := loadRevision(head, paths, fset)
+	if err != nil {
+		return err
+	}
+
+	changes := diffDecls(oldRev.decls, newRev.decls)
+	breaking := 0
+	for _, c := range changes {
+		classifyChange(c, oldRev.pkgs[c.Package], newRev.pkgs[c.Package])
+		if c.Breaking {
+			breaking++
+		}
+	}
+	if err := emitChanges(changes); err != nil {
+		return err
+	}
+	if breaking > 0 {
+		return fmt.Errorf("found %d breaking change(s) between %s and %s", breaking, base, head)
+	}
+	return nil
+}
+
+// Extract and type-check the declarations of all matching files at a git revision
+func loadRevision(rev string, paths []string, fset *token.FileSet) (*revision, error) {
+	files, err := gitListFiles(rev, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	extensions := splitExtensions()
+	excludes := splitExcludes()
+	excludeDirs := splitExcludeDirs()
+
+	r := &revision{pkgs: make(map[string]*types.Package)}
+	filesByPkg := make(map[string][]*ast.File)
+	var pkgDirs []string
+	for _, file := range files {
+		if isExcluded(file, excludes) || !hasExtension(file, extensions) || inExcludedDir(file, excludeDirs) {
+			continue
+		}
+		src, err := gitReadFile(rev, file)
+		if err != nil {
+			return nil, err
+		}
+		filename := filepath.Join(workDir, file)
+		f, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+		if err != nil {
+			return nil, fmt.Errorf("%s (at %s): %v", file, rev, err)
+		}
+		if !buildMatches(filename, f) {
+			continue
+		}
+
+		pkgDir := filepath.ToSlash(filepath.Dir(file))
+		if _, ok := filesByPkg[pkgDir]; !ok {
+			pkgDirs = append(pkgDirs, pkgDir)
+		}
+		filesByPkg[pkgDir] = append(filesByPkg[pkgDir], f)
+
+		decls := fileDecls(f, filename, fset, nil)
+		for _, d := range decls {
+			d.Package = pkgDir
+		}
+		r.decls = append(r.decls, decls...)
+	}
+
+	// Type-check each package; errors are silenced and partial information is kept
+	check := types.Config{Importer: newRevisionImporter(rev, fset), Error: conf.Error}
+	for _, pkgDir := range pkgDirs {
+		pkg, _ := check.Check(pkgDir, fset, filesByPkg[pkgDir], nil)
+		r.pkgs[pkgDir] = pkg
+	}
+	return r, nil
+}
+
+// revisionImporter imports the packages of the module at a git revision from
+// their source at that revision, and other packages with the importer of the
+// working tree
+type revisionImporter struct {
+	rev        string
+	fset       *token.FileSet
+	modulePath string
+	moduleDir  string // relative to the working directory
+	pkgs       map[string]*types.Package
+	checking   map[string]bool
+}
+
+func newRevisionImporter(rev string, fset *token.FileSet) *revisionImporter {
+	imp := &revisionImporter{
+		rev:      rev,
+		fset:     fset,
+		pkgs:     make(map[string]*types.Package),
+		checking: make(map[string]bool),
+	}
+	imp.modulePath, imp.moduleDir = revisionModule(rev)
+	return imp
+}
+
+// Find the go.mod of the working directory or of its closest parent at a
+// revision, and return its module path and directory
+func revisionModule(rev string) (modulePath, moduleDir string) {
+	prefix, err := gitOutput("rev-parse", "--show-prefix")
+	if err != nil {
+		return "", ""
+	}
+	dir := "."
+	for i := strings.Count(string(prefix), "/"); i >= 0; i-- {
+		if data, err := gitReadFile(rev, filepath.Join(dir, "go.mod")); err == nil {
+			return modfile.ModulePath(data), dir
+		}
+		dir = filepath.Join(dir, "..")
+	}
+	return "", ""
+}
+
+func (imp *revisionImporter) Import(path string) (*types.Package, error) {
+	return imp.ImportFrom(path, workDir, 0)
+}
+
+func (imp *revisionImporter) ImportFrom(path, dir string, mode types.ImportMode) (*types.Package, error) {
+	if pkg, ok := imp.pkgs[path]; ok {
+		return pkg, nil
+	}
+
+	pkgDir, ok := imp.packageDir(path)
+	if !ok {
+		pkg, err := conf.Importer.(types.ImporterFrom).ImportFrom(path, dir, mode)
+		if err != nil {
+			return nil, err
+		}
+		imp.pkgs[path] = pkg
+		return pkg, nil
+	}
+
+	if imp.checking[path] {
+		return nil, fmt.Errorf("import cycle through %s", path)
+	}
+	imp.checking[path] = true
+	defer delete(imp.checking, path)
+
+	files, err := imp.parsePackage(pkgDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no Go files for %s in %s at %s", path, pkgDir, imp.rev)
+	}
+	check := types.Config{Importer: imp, Error: conf.Error}
+	pkg, _ := check.Check(path, imp.fset, files, nil)
+	imp.pkgs[path] = pkg
+	return pkg, nil
+}
+
+// Helper function to get the directory of a package of the module, relative
+// to the working directory
+func (imp *revisionImporter) packageDir(path string) (string, bool) {
+	if imp.modulePath == "" {
+		return "", false
+	}
+	if path == imp.modulePath {
+		return imp.moduleDir, true
+	}
+	if rest, ok := strings.CutPrefix(path, imp.modulePath+"/"); ok {
+		return filepath.Join(imp.moduleDir, filepath.FromSlash(rest)), true
+	}
+	return "", false
+}
+
+// Parse the files of a package directory at the revision, with the same
+// filters as the listed files. Files with parse errors are skipped.
+func (imp *revisionImporter) parsePackage(pkgDir string) ([]*ast.File, error) {
+	files, err := gitListFiles(imp.rev, []string{pkgDir})
+	if err != nil {
+		return nil, err
+	}
+	extensions := splitExtensions()
+	excludes := splitExcludes()
+
+	var parsed []*ast.File
+	for _, file := range files {
+		if filepath.Dir(file) != filepath.Clean(pkgDir) || isExcluded(file, excludes) || !hasExtension(file, extensions) {
+			continue
+		}
+		src, err := gitReadFile(imp.rev, file)
+		if err != nil {
+			return nil, err
+		}
+		filename := filepath.Join(workDir, file)
+		f, err := parser.ParseFile(imp.fset, filename, src, parser.ParseComments)
+		if err != nil || !buildMatches(filename, f) {
+			continue
+		}
+		parsed = append(parsed, f)
+	}
+	return parsed, nil
+}
+
+// Match declarations by package, receiver and name and report the differences
+func diffDecls(oldDecls, newDecls []*Decl) []*APIChange {
+	oldByKey := declsByKey(oldDecls)
+	newByKey := declsByKey(newDecls)
+
+	// Collect all keys
+	keys := make(map[string]bool)
+	for key := range oldByKey {
+		keys[key] = true
+	}
+	for key := range newByKey {
+		keys[key] = true
+	}
+
+	var changes []*APIChange
+	for key := range keys {
+		olds, news := oldByKey[key], newByKey[key]
+		for i := 0; i < len(olds) || i < len(news); i++ {
+			switch {
+			case i >= len(olds):
+				changes = append(changes, newAPIChange(ChangeAdded, nil, news[i]))
+			case i >= len(news):
+				changes = append(changes, newAPIChange(ChangeRemoved, olds[i], nil))
+			case olds[i].Signature != news[i].Signature:
+				changes = append(changes, newAPIChange(ChangeChanged, olds[i], news[i]))
+			}
+		}
+	}
+
+	// Sort changes by package and name
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Package != changes[j].Package {
+			return changes[i].Package < changes[j].Package
+		}
+		if changes[i].Name != changes[j].Name {
+			return changes[i].Name < changes[j].Name
+		}
+		return changes[i].Change < changes[j].Change
+	})
+	return changes
+}
+
+func newAPIChange(change string, oldDecl, newDecl *Decl) *APIChange {
+	c := &APIChange{Change: change}
+	d := newDecl
+	if d == nil {
+		d = oldDecl
+	}
+	c.Package = d.Package
+	c.Kind = d.Kind
+	c.Name = qualifiedName(d)
+	if oldDecl != nil {
+		c.Old = oldDecl.Signature
+	}
+	if newDecl != nil {
+		c.New = newDecl.Signature
+	}
+	return c
+}
+
+// Group declarations by package, receiver and name, in source order
+func declsByKey(decls []*Decl) map[string][]*Decl {
+	byKey := make(map[string][]*Decl)
+	for _, d := range decls {
+		key := d.Package + "\x00" + qualifiedName(d)
+		byKey[key] = append(byKey[key], d)
+	}
+	return byKey
+}
+
+// Helper function to get the name of a declaration, prefixed by its receiver base type for methods
+func qualifiedName(d *Decl) string {
+	if d.Receiver == "" {
+		return d.Name
+	}
+	return receiverBase(d.Receiver) + "." + d.Name
+}
+
+// Helper function to get the type name of a receiver, without pointer and type parameters
+func receiverBase(recv string) string {
+	recv = strings.TrimPrefix(recv, "*")
+	if i := strings.Index(recv, "["); i >= 0 {
+		recv = recv[:i]
+	}
+	return recv
+}
+
+// Render API changes in the selected output format
+func emitChanges(changes []*APIChange) error {
+	switch outputFormat {
+	case formatJSON:
+		if changes == nil {
+			changes = []*APIChange{}
+		}
+		enc := newJSONEncoder()
+		enc.SetIndent("", "  ")
+		return enc.Encode(changes)
+	case formatJSONL:
+		enc := newJSONEncoder()
+		for _, c := range changes {
+			if err := enc.Encode(c); err != nil {
+				return err
+			}
+		}
+	default:
+		for _, c := range changes {
+			fmt.Println(formatChangeLine(c))
+		}
+	}
+	return nil
+}
+
+// Format an API change as a single line of text output
+func formatChangeLine(c *APIChange) string {
+	var line string
+	switch c.Change {
+	case ChangeAdded:
+		line = fmt.Sprintf("%s: + %s", c.Package, c.New)
+	case ChangeRemoved:
+		line = fmt.Sprintf("%s: - %s", c.Package, c.Old)
+	default:
+		line = fmt.Sprintf("%s: ~ %s -> %s", c.Package, c.Old, c.New)
+	}
+	if c.Breaking {
+		line += " // breaking: " + c.Reason
+	}
+	return line
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"go/token"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// initTestRepo creates a git repository with one commit per set of files
+func initTestRepo(t *testing.T, revisions ...map[string]string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	tmpDir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = tmpDir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+		)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+	git("init", "-q")
+	for _, files := range revisions {
+		writeTestFiles(t, tmpDir, files)
+		git("add", "-A")
+		git("commit", "-q", "-m", "revision")
+	}
+	return tmpDir
+}
+
+func TestRunDiff(t *testing.T) {
+	workDir = initTestRepo(t,
+		map[string]string{
+			"a/a.go": `package a
+				func Kept() {}
+				func Removed() {}
+				func Changed(a int) {}
+				type T struct{}
+				func (t *T) Method() {}
+				func (t T) Value() {}`,
+			"b/b.go": `package b
+				var Gone = 1`,
+			"c/c.go": `package c
+				type Config struct {
+					Name    string
+					Port    int
+					Timeout int
+				}
+				type Store interface {
+					Get(key string) string
+				}
+				const Limit int64 = 10
+				const Version = "1"
+				var Default = Config{}
+				func helper(a int) {}`,
+		},
+		map[string]string{
+			"a/a.go": `package a
+				func Kept() {}
+				func Changed(a string) {}
+				func Added() error { return nil }
+				type T struct{}
+				func (t T) Method() {}
+				func (t *T) Value() {}`,
+			"b/b.go": "",
+			"c/c.go": `package c
+				type Config struct {
+					Name    string
+					Port    string
+					Retries int
+				}
+				type Store interface {
+					Get(key string) string
+					Set(key string, value string)
+				}
+				const Limit int32 = 10
+				const Version = "2"
+				var Default = Config{Name: "x"}
+				func helper(a string) {}`,
+		},
+	)
+	includePrivate = true
+	skipValues = true
+	maxValueLength = 30
+	fileExtensions = ".go"
+	excludeSuffixes = "_test.go"
+	t.Cleanup(func() {
+		includePrivate, skipValues, workDir = false, false, ""
+	})
+
+	var gotErr error
+	output := captureOutput(func() {
+		gotErr = runDiff([]string{"HEAD~1", "HEAD", "./..."}, token.NewFileSet())
+	})
+	if gotErr == nil || !strings.Contains(gotErr.Error(), "found 7 breaking change(s)") {
+		t.Errorf("unexpected error: %v", gotErr)
+	}
+
+	got := strings.TrimSpace(output)
+	want := strings.Join([]string{
+		"a: + func Added() error",
+		"a: ~ func Changed(a int) -> func Changed(a string) // breaking: signature changed from func(int) to func(string)",
+		"a: - func Removed() // breaking: removed",
+		"a: ~ func (*T) Method() -> func (T) Method()",
+		"a: ~ func (T) Value() -> func (*T) Value() // breaking: receiver changed from value to pointer",
+		"b: - var Gone int // breaking: removed",
+		"c: ~ type Config struct { Name string; Port int; Timeout int } -> type Config struct { Name string; Port string; Retries int } // breaking: field Port changed from int to string; field Timeout removed",
+		"c: ~ const Limit int64 -> const Limit int32 // breaking: type changed from int64 to int32",
+		"c: ~ type Store interface { Get(key string) string } -> type Store interface { Get(key string) string; Set(key string, value string) } // breaking: method Set added to interface",
+		"c: ~ const Version = \"1\" -> const Version = \"2\"",
+		"c: ~ func helper(a int) -> func helper(a string)",
+	}, "\n")
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRunDiffModuleImports(t *testing.T) {
+	workDir = initTestRepo(t,
+		map[string]string{
+			"go.mod": "module example.com/m\n\ngo 1.21\n",
+			"a/a.go": `package a
+				import (
+					"io"
+
+					"example.com/m/b"
+				)
+				func F(x b.T) {}
+				func G(r io.Reader, t b.T) {}`,
+			"b/b.go": `package b
+				type T struct{}
+				type U struct{}`,
+		},
+		map[string]string{
+			"a/a.go": `package a
+				import (
+					"io"
+
+					"example.com/m/b"
+				)
+				func F(y b.T) {}
+				func G(r io.Reader, t b.U) {}`,
+		},
+	)
+	skipValues = true
+	fileExtensions = ".go"
+	excludeSuffixes = "_test.go"
+	t.Cleanup(func() {
+		skipValues, workDir = false, ""
+	})
+
+	var gotErr error
+	output := captureOutput(func() {
+		gotErr = runDiff([]string{"HEAD~1", "HEAD", "./a"}, token.NewFileSet())
+	})
+	if gotErr == nil || !strings.Contains(gotErr.Error(), "found 1 breaking change(s)") {
+		t.Errorf("unexpected error: %v", gotErr)
+	}
+
+	got := strings.TrimSpace(output)
+	want := strings.Join([]string{
+		"a: ~ func F(x b.T) -> func F(y b.T)",
+		"a: ~ func G(r io.Reader, t b.T) -> func G(r io.Reader, t b.U) // breaking: signature changed from func(io.Reader, example.com/m/b.T) to func(io.Reader, example.com/m/b.U)",
+	}, "\n")
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"go/ast"
+	"go/doc"
+	"strings"
+)
+
+// Doc comment modes
+const (
+	docFirst = "first"
+	docFull  = "full"
+)
+
+var (
+	docMode  string
+	checkDoc bool
+
+	// Number of undocumented exported declarations found in -check-doc mode
+	undocumentedCount int
+)
+
+// Keep only the exported declarations that have no doc comment. Methods
+// are only considered when their receiver type is exported too.
+func undocumented(decls []*Decl) []*Decl {
+	var missing []*Decl
+	for _, d := range decls {
+		if d.Doc != "" || !d.Exported {
+			continue
+		}
+		if d.Receiver != "" && !ast.IsExported(receiverBase(d.Receiver)) {
+			continue
+		}
+		missing = append(missing, d)
+	}
+	return missing
+}
+
+// Helper function to get the first sentence of a doc comment
+func synopsis(text string) string {
+	return new(doc.Package).Synopsis(text)
+}
+
+// Helper function to format a doc comment as // comment lines
+func docCommentLines(text string) []string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight("// "+line, " ")
+	}
+	return lines
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func TestDocComments(t *testing.T) {
+	code := `package test
+
+// Client talks to the server. It is safe for concurrent use.
+type Client struct{}
+
+// Do sends a request.
+//
+// It retries on failure.
+func (c *Client) Do() error { return nil }
+
+func (c *Client) Close() error { return nil }
+
+// Grouped constants.
+const (
+	A = 1
+	B = 2
+)
+
+type hidden struct{}
+
+func (h hidden) Exported() {}
+
+func Undocumented() {}
+`
+	tests := []struct {
+		name     string
+		docMode  string
+		checkDoc bool
+		want     []string
+	}{
+		{
+			name:    "first sentence",
+			docMode: docFirst,
+			want: []string{
+				"test.go: type Client struct { } // Client talks to the server.",
+				"test.go: func (*Client) Do() error // Do sends a request.",
+				"test.go: func (*Client) Close() error",
+				"test.go: const A = 1 // Grouped constants.",
+				"test.go: const B = 2 // Grouped constants.",
+				"test.go: func (hidden) Exported()",
+				"test.go: func Undocumented()",
+			},
+		},
+		{
+			name:    "full text",
+			docMode: docFull,
+			want: []string{
+				"test.go: // Client talks to the server. It is safe for concurrent use.",
+				"test.go: type Client struct { }",
+				"test.go: // Do sends a request.",
+				"test.go: //",
+				"test.go: // It retries on failure.",
+				"test.go: func (*Client) Do() error",
+				"test.go: func (*Client) Close() error",
+				"test.go: // Grouped constants.",
+				"test.go: const A = 1",
+				"test.go: // Grouped constants.",
+				"test.go: const B = 2",
+				"test.go: func (hidden) Exported()",
+				"test.go: func Undocumented()",
+			},
+		},
+		{
+			name:     "check missing doc comments",
+			checkDoc: true,
+			want: []string{
+				"test.go: func (*Client) Close() error",
+				"test.go: func Undocumented()",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			includePrivate = false
+			skipValues = false
+			maxValueLength = 30
+			docMode = tt.docMode
+			checkDoc = tt.checkDoc
+			undocumentedCount = 0
+			t.Cleanup(func() {
+				docMode = ""
+				checkDoc = false
+			})
+
+			filename := createTestFile(t, code)
+			output := captureOutput(func() {
+				if err := processFile(filename, token.NewFileSet()); err != nil {
+					t.Fatal(err)
+				}
+			})
+
+			got := strings.Split(strings.TrimSpace(output), "\n")
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+			if tt.checkDoc && undocumentedCount != len(tt.want) {
+				t.Errorf("undocumentedCount = %d, want %d", undocumentedCount, len(tt.want))
+			}
+		})
+	}
+}
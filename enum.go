@@ -0,0 +1,393 @@
+package main
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+var (
+	// Enum information of the packages, by absolute directory
+	dirEnums   = make(map[string]*enumScan)
+	dirEnumsMu sync.Mutex
+)
+
+// enumScan holds the enum information of the files of a directory
+type enumScan struct {
+	stringers map[string]bool     // types with a String() string method
+	members   map[string][]string // members of each enum, by package and type
+}
+
+// constGroup resolves the implicit repetition of a const declaration and
+// computes the values of its constants
+type constGroup struct {
+	specs  map[*ast.ValueSpec]*ast.ValueSpec // explicit spec of each spec
+	values map[*ast.Ident]constant.Value
+	enum   string // type of the constants, if the group is an enum
+}
+
+// Resolve a const declaration: specs without values repeat the type and
+// values of the previous spec, and iota is the index of the spec. Values
+// are computed using the constants of the file evaluated so far (known),
+// which is updated with the constants of the declaration.
+func newConstGroup(decl *ast.GenDecl, known map[string]constant.Value) *constGroup {
+	g := &constGroup{
+		specs:  make(map[*ast.ValueSpec]*ast.ValueSpec),
+		values: make(map[*ast.Ident]constant.Value),
+	}
+	if decl.Tok != token.CONST {
+		return g
+	}
+
+	var last *ast.ValueSpec
+	for i, spec := range decl.Specs {
+		s, ok := spec.(*ast.ValueSpec)
+		if !ok {
+			continue
+		}
+		if len(s.Values) == 0 && last != nil {
+			implicit := *s
+			implicit.Type, implicit.Values = last.Type, last.Values
+			s = &implicit
+		} else {
+			last = s
+		}
+		g.specs[spec.(*ast.ValueSpec)] = s
+
+		for j, name := range s.Names {
+			if j >= len(s.Values) {
+				break
+			}
+			val := evalConst(s.Values[j], int64(i), known)
+			if val == nil {
+				continue
+			}
+			g.values[name] = val
+			if name.Name != "_" {
+				known[name.Name] = val
+			}
+		}
+	}
+
+	g.enum = enumType(decl, g.specs)
+	return g
+}
+
+// Get the explicit form of a spec of the declaration
+func (g *constGroup) spec(s *ast.ValueSpec) *ast.ValueSpec {
+	if explicit, ok := g.specs[s]; ok {
+		return explicit
+	}
+	return s
+}
+
+// Replace the value of an iota-based or implicitly repeated constant with
+// the computed one, like the type checker does
+func (g *constGroup) resolveValueSpecEntry(entry *valueSpecEntry, spec *ast.ValueSpec, i int) {
+	explicit := g.spec(spec)
+	if skipValues || i >= len(explicit.Values) {
+		return
+	}
+	if explicit == spec && !usesIota(spec.Values[i]) {
+		return
+	}
+	if val, ok := g.values[entry.name]; ok && val.Kind() != constant.Unknown {
+		entry.value = val.ExactString()
+	}
+}
+
+// Get a spec of the declaration that declares the same constants on its
+// own: specs whose values depend on iota, or are implicitly repeated, get
+// the computed values instead. Returns nil if a value cannot be computed.
+func (g *constGroup) standaloneSpec(s *ast.ValueSpec) *ast.ValueSpec {
+	explicit := g.spec(s)
+	dependent := explicit != s
+	for _, value := range s.Values {
+		dependent = dependent || usesIota(value)
+	}
+	if !dependent {
+		return s
+	}
+
+	spec := &ast.ValueSpec{Names: s.Names}
+	if explicit.Type != nil {
+		// Printed as is, at the position of the names
+		spec.Type = &ast.Ident{NamePos: s.End(), Name: types.ExprString(explicit.Type)}
+	}
+	for _, name := range s.Names {
+		val, ok := g.values[name]
+		if !ok || val.Kind() == constant.Unknown {
+			return nil
+		}
+		spec.Values = append(spec.Values, &ast.Ident{NamePos: s.End(), Name: val.ExactString()})
+	}
+	return spec
+}
+
+// Get the type of an enum: a parenthesized group of at least two constants
+// all declared with the same type, defined in the package
+func enumType(decl *ast.GenDecl, specs map[*ast.ValueSpec]*ast.ValueSpec) string {
+	if !decl.Lparen.IsValid() {
+		return ""
+	}
+	name, count := "", 0
+	for _, spec := range decl.Specs {
+		s := specs[spec.(*ast.ValueSpec)]
+		ident, ok := s.Type.(*ast.Ident)
+		if !ok || (name != "" && ident.Name != name) {
+			return ""
+		}
+		name = ident.Name
+		count += len(s.Names)
+	}
+	if count < 2 || types.Universe.Lookup(name) != nil {
+		return ""
+	}
+	return name
+}
+
+// Evaluate a constant expression, or return nil if it cannot be computed
+// from the file alone
+func evalConst(expr ast.Expr, iota int64, known map[string]constant.Value) constant.Value {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		val := constant.MakeFromLiteral(e.Value, e.Kind, 0)
+		if val.Kind() == constant.Unknown {
+			return nil
+		}
+		return val
+	case *ast.Ident:
+		switch e.Name {
+		case "iota":
+			return constant.MakeInt64(iota)
+		case "true", "false":
+			return constant.MakeBool(e.Name == "true")
+		}
+		return known[e.Name]
+	case *ast.ParenExpr:
+		return evalConst(e.X, iota, known)
+	case *ast.UnaryExpr:
+		x := evalConst(e.X, iota, known)
+		if x == nil {
+			return nil
+		}
+		switch e.Op {
+		case token.ADD, token.SUB, token.XOR, token.NOT:
+			return constant.UnaryOp(e.Op, x, 0)
+		}
+	case *ast.BinaryExpr:
+		x, y := evalConst(e.X, iota, known), evalConst(e.Y, iota, known)
+		if x == nil || y == nil {
+			return nil
+		}
+		return evalBinary(e.Op, x, y)
+	case *ast.CallExpr:
+		if len(e.Args) != 1 || e.Ellipsis.IsValid() {
+			return nil
+		}
+		arg := evalConst(e.Args[0], iota, known)
+		if arg == nil {
+			return nil
+		}
+		if ident, ok := e.Fun.(*ast.Ident); ok && ident.Name == "len" {
+			if arg.Kind() != constant.String {
+				return nil
+			}
+			return constant.MakeInt64(int64(len(constant.StringVal(arg))))
+		}
+		// Conversion to a named or basic type, e.g. Color(iota)
+		switch e.Fun.(type) {
+		case *ast.Ident, *ast.SelectorExpr, *ast.ParenExpr:
+			return arg
+		}
+	}
+	return nil
+}
+
+// Evaluate a binary operation on constants, or return nil if it is invalid
+func evalBinary(op token.Token, x, y constant.Value) constant.Value {
+	isNumeric := func(v constant.Value) bool {
+		switch v.Kind() {
+		case constant.Int, constant.Float, constant.Complex:
+			return true
+		}
+		return false
+	}
+
+	switch op {
+	case token.SHL, token.SHR:
+		s, ok := constant.Uint64Val(constant.ToInt(y))
+		if !ok || x.Kind() != constant.Int || s > 1024 {
+			return nil
+		}
+		return constant.Shift(x, op, uint(s))
+	case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ:
+		if x.Kind() != y.Kind() && !(isNumeric(x) && isNumeric(y)) {
+			return nil
+		}
+		return constant.MakeBool(constant.Compare(x, op, y))
+	case token.LAND, token.LOR:
+		if x.Kind() != constant.Bool || y.Kind() != constant.Bool {
+			return nil
+		}
+		return constant.BinaryOp(x, op, y)
+	case token.ADD:
+		if x.Kind() == constant.String && y.Kind() == constant.String {
+			return constant.BinaryOp(x, op, y)
+		}
+	}
+
+	if !isNumeric(x) || !isNumeric(y) {
+		return nil
+	}
+	switch op {
+	case token.QUO, token.REM:
+		if constant.Sign(y) == 0 {
+			return nil
+		}
+		if op == token.QUO && x.Kind() == constant.Int && y.Kind() == constant.Int {
+			// Integer division
+			op = token.QUO_ASSIGN
+		}
+	case token.AND, token.OR, token.XOR, token.AND_NOT:
+		if x.Kind() != constant.Int || y.Kind() != constant.Int {
+			return nil
+		}
+	case token.ADD, token.SUB, token.MUL:
+	default:
+		return nil
+	}
+	if op == token.REM && (x.Kind() != constant.Int || y.Kind() != constant.Int) {
+		return nil
+	}
+	return constant.BinaryOp(x, op, y)
+}
+
+// Get the types of a file that have a String() string method
+func fileStringers(f *ast.File) []string {
+	var names []string
+	for _, decl := range f.Decls {
+		fd, ok := decl.(*ast.FuncDecl)
+		if !ok || fd.Recv == nil || len(fd.Recv.List) == 0 || fd.Name.Name != "String" {
+			continue
+		}
+		ft := fd.Type
+		if ft.Params.NumFields() != 0 || ft.Results.NumFields() != 1 {
+			continue
+		}
+		if ident, ok := ft.Results.List[0].Type.(*ast.Ident); !ok || ident.Name != "string" {
+			continue
+		}
+		names = append(names, receiverBase(types.ExprString(fd.Recv.List[0].Type)))
+	}
+	return names
+}
+
+// Helper function to get the enum information of the directory of a file.
+// Directories are scanned once, with the extension, exclude and build
+// filters, when a type is first listed.
+func scanEnums(filename string) *enumScan {
+	dir := filepath.Dir(filename)
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(workDir, dir)
+	}
+
+	dirEnumsMu.Lock()
+	defer dirEnumsMu.Unlock()
+	if scan, ok := dirEnums[dir]; ok {
+		return scan
+	}
+	scan := &enumScan{
+		stringers: make(map[string]bool),
+		members:   make(map[string][]string),
+	}
+	dirEnums[dir] = scan
+
+	entries, _ := os.ReadDir(dir)
+	extensions := splitExtensions()
+	excludes := splitExcludes()
+	for _, entry := range entries {
+		name := filepath.Join(dir, entry.Name())
+		if entry.IsDir() || isExcluded(name, excludes) || !hasExtension(name, extensions) || !fileMatchesBuild(name) {
+			continue
+		}
+		f, err := parser.ParseFile(token.NewFileSet(), name, nil, parser.SkipObjectResolution)
+		if f == nil && err != nil {
+			continue
+		}
+		for _, typeName := range fileStringers(f) {
+			scan.stringers[typeName] = true
+		}
+		for typeName, members := range fileEnumMembers(f) {
+			key := f.Name.Name + "." + typeName
+			scan.members[key] = append(scan.members[key], members...)
+		}
+	}
+	return scan
+}
+
+// Get the listed constants of the enums of a file, by type
+func fileEnumMembers(f *ast.File) map[string][]string {
+	members := make(map[string][]string)
+	known := make(map[string]constant.Value)
+	for _, decl := range f.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.CONST {
+			continue
+		}
+		consts := newConstGroup(gd, known)
+		if consts.enum == "" {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			s := spec.(*ast.ValueSpec)
+			if hasBadNode(s) || (!includePrivate && !s.Names[0].IsExported()) {
+				continue
+			}
+			for _, name := range s.Names {
+				members[consts.enum] = append(members[consts.enum], name.Name)
+			}
+		}
+	}
+	return members
+}
+
+// Helper function to check if a listed type has a String() string method
+// in any file of its directory
+func hasStringMethod(d *Decl) bool {
+	return scanEnums(d.File).stringers[d.Name]
+}
+
+// Note the members of the enum types of a file, declared in any file of its
+// package, or in the file itself if it is not part of the scanned files
+func annotateEnums(decls []*Decl, f *ast.File) {
+	for _, td := range decls {
+		if td.Kind != KindType {
+			continue
+		}
+		td.Members = scanEnums(td.File).members[f.Name.Name+"."+td.Name]
+		if len(td.Members) == 0 {
+			for _, d := range decls {
+				if d.Kind == KindConst && d.Enum == td.Name {
+					td.Members = append(td.Members, d.Name)
+				}
+			}
+		}
+		if len(td.Members) > 0 {
+			td.Stringer = hasStringMethod(td)
+		}
+	}
+}
+
+// Helper function to format the note of an enum type
+func enumNote(d *Decl) string {
+	if d.Stringer {
+		return "enum with String()"
+	}
+	return "enum without String()"
+}
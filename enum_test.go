@@ -0,0 +1,178 @@
+package main
+
+import (
+	"go/constant"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEnums(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"colors.go": `package colors
+			type Color int
+			const (
+				Red Color = iota
+				Green
+				Blue
+			)
+			type Level uint8
+			const (
+				Debug Level = iota + 1
+				Info
+			)
+			const Max = 3`,
+		"color_string.go": `package colors
+			func (c Color) String() string { return "" }`,
+	}
+	writeTestFiles(t, tmpDir, files)
+
+	includePrivate = false
+	skipValues = false
+	maxValueLength = 30
+	fileExtensions = ".go"
+	excludeSuffixes = "_test.go"
+	workDir = tmpDir
+	groupView = true
+	t.Cleanup(func() { groupView, workDir = false, "" })
+
+	output := captureOutput(func() {
+		if err := processPath(tmpDir, token.NewFileSet()); err != nil {
+			t.Fatal(err)
+		}
+		if err := flushDecls(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	want := strings.Join([]string{
+		"colors.go: type Color int // enum with String()",
+		"colors.go: const Red Color = 0",
+		"colors.go: const Green Color = 1",
+		"colors.go: const Blue Color = 2",
+		"color_string.go: func (Color) String() string",
+		"colors.go: type Level uint8 // enum without String()",
+		"colors.go: const Debug Level = 1",
+		"colors.go: const Info Level = 2",
+		"colors.go: const Max = 3",
+	}, "\n")
+	if got := strings.TrimSpace(output); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Enums are noted without grouping too, and their members are listed
+	// in JSON output
+	groupView = false
+	output = captureOutput(func() {
+		if err := processPath(tmpDir, token.NewFileSet()); err != nil {
+			t.Fatal(err)
+		}
+	})
+	for _, want := range []string{
+		"colors.go: type Color int // enum with String()\n",
+		"colors.go: type Level uint8 // enum without String()\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output does not contain %q:\n%s", want, output)
+		}
+	}
+
+	outputFormat = formatJSONL
+	t.Cleanup(func() { outputFormat = formatText })
+	output = captureOutput(func() {
+		if err := processPath(tmpDir, token.NewFileSet()); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if want := `"name":"Color","signature":"type Color int","exported":true,"members":["Red","Green","Blue"],"stringer":true`; !strings.Contains(output, want) {
+		t.Errorf("output does not contain %q:\n%s", want, output)
+	}
+}
+
+func TestEnumsAcrossFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTestFiles(t, tmpDir, map[string]string{
+		"t.go": `package colors
+			type Color int`,
+		"c.go": `package colors
+			const (
+				Red Color = iota
+				Green
+			)`,
+		"s.go": `package colors
+			func (c Color) String() string { return "" }`,
+	})
+
+	includePrivate = false
+	skipValues = false
+	maxValueLength = 30
+	fileExtensions = ".go"
+	excludeSuffixes = "_test.go"
+	workDir = tmpDir
+	t.Cleanup(func() { workDir, outputFormat = "", formatText })
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{formatText, "t.go: type Color int // enum with String()\n"},
+		{formatJSONL, `"name":"Color","signature":"type Color int","exported":true,"members":["Red","Green"],"stringer":true`},
+		{formatJSON, `"members": [
+      "Red",
+      "Green"
+    ],
+    "stringer": true`},
+		{formatMarkdown, "```go\ntype Color int\n```\n\n_enum with String()_\n"},
+	}
+	for _, tt := range tests {
+		outputFormat = tt.format
+		output := captureOutput(func() {
+			if err := processFile(filepath.Join(tmpDir, "t.go"), token.NewFileSet()); err != nil {
+				t.Fatal(err)
+			}
+			if err := flushDecls(); err != nil {
+				t.Fatal(err)
+			}
+		})
+		if !strings.Contains(output, tt.want) {
+			t.Errorf("%s output does not contain %q:\n%s", tt.format, tt.want, output)
+		}
+	}
+}
+
+func TestEvalConst(t *testing.T) {
+	tests := []struct {
+		expr string
+		iota int64
+		want string // empty when the value cannot be computed
+	}{
+		{"iota", 3, "3"},
+		{"1 << (10 * iota)", 2, "1048576"},
+		{"Color(iota) + 1", 1, "2"},
+		{"7 / 2", 0, "3"},
+		{"7.0 / 2", 0, "7/2"},
+		{`"a" + "b"`, 0, `"ab"`},
+		{`len("abc")`, 0, "3"},
+		{"Base * 2", 0, "20"},
+		{"1 / 0", 0, ""},
+		{"30 * time.Second", 0, ""},
+		{"unknown + 1", 0, ""},
+	}
+	known := map[string]constant.Value{"Base": constant.MakeInt64(10)}
+	for _, tt := range tests {
+		expr, err := parser.ParseExpr(tt.expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := ""
+		if val := evalConst(expr, tt.iota, known); val != nil {
+			got = val.ExactString()
+		}
+		if got != tt.want {
+			t.Errorf("evalConst(%s) with iota %d = %q, want %q", tt.expr, tt.iota, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/scanner"
+	"go/token"
+	"os"
+)
+
+var (
+	keepGoing bool
+
+	// Errors recorded in -keep-going mode, reported at the end of the run
+	collectedErrors []string
+)
+
+// In -keep-going mode, record the error and return nil so that processing
+// continues; otherwise return the error unchanged
+func keepGoingOn(err error) error {
+	if err == nil || !keepGoing {
+		return err
+	}
+
+	// Record each parse error with its position
+	var list scanner.ErrorList
+	if errors.As(err, &list) {
+		for _, e := range list {
+			pos := e.Pos
+			pos.Filename = relativePath(pos.Filename)
+			collectedErrors = append(collectedErrors, fmt.Sprintf("%s: %s", pos, e.Msg))
+		}
+		return nil
+	}
+	collectedErrors = append(collectedErrors, err.Error())
+	return nil
+}
+
+// Helper function to check if a node of a partially parsed file contains
+// syntax errors, in which case it is not listed
+func hasBadNode(node ast.Node) bool {
+	bad := false
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n.(type) {
+		case *ast.BadExpr, *ast.BadDecl:
+			bad = true
+		}
+		return !bad
+	})
+	return bad
+}
+
+// Get a copy of a partially parsed file where the declarations and specs
+// containing a syntax error of err are replaced or marked with bad nodes,
+// as the parser does not always produce them, e.g. for "func Bad( {"
+func markSyntaxErrors(f *ast.File, fset *token.FileSet, err error) *ast.File {
+	var list scanner.ErrorList
+	tf := fset.File(f.Package)
+	if !errors.As(err, &list) || tf == nil {
+		return f
+	}
+	var positions []token.Pos
+	for _, e := range list {
+		if e.Pos.Filename == tf.Name() && e.Pos.Offset >= 0 && e.Pos.Offset <= tf.Size() {
+			positions = append(positions, tf.Pos(e.Pos.Offset))
+		}
+	}
+	hasError := func(node ast.Node) bool {
+		for _, pos := range positions {
+			if node.Pos() <= pos && pos <= node.End() {
+				return true
+			}
+		}
+		return false
+	}
+
+	marked := *f
+	marked.Decls = make([]ast.Decl, 0, len(f.Decls))
+	for _, decl := range f.Decls {
+		if !hasError(decl) {
+			marked.Decls = append(marked.Decls, decl)
+			continue
+		}
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok {
+			marked.Decls = append(marked.Decls, &ast.BadDecl{From: decl.Pos(), To: decl.End()})
+			continue
+		}
+		// Specs are kept in place, as iota is their index
+		markedDecl := *gd
+		markedDecl.Specs = make([]ast.Spec, 0, len(gd.Specs))
+		for _, spec := range gd.Specs {
+			if hasError(spec) {
+				bad := &ast.BadExpr{From: spec.Pos(), To: spec.End()}
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					markedSpec := *s
+					markedSpec.Type = bad
+					spec = &markedSpec
+				case *ast.ValueSpec:
+					markedSpec := *s
+					markedSpec.Type = bad
+					spec = &markedSpec
+				}
+			}
+			markedDecl.Specs = append(markedDecl.Specs, spec)
+		}
+		marked.Decls = append(marked.Decls, &markedDecl)
+	}
+	return &marked
+}
+
+// Print a summary of the errors recorded in -keep-going mode
+func reportErrors() error {
+	if len(collectedErrors) == 0 {
+		return nil
+	}
+	fmt.Fprintf(os.Stderr, "\n%d error(s):\n", len(collectedErrors))
+	for _, e := range collectedErrors {
+		fmt.Fprintln(os.Stderr, e)
+	}
+	n := len(collectedErrors)
+	collectedErrors = nil
+	return fmt.Errorf("found %d error(s)", n)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"go/token"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestKeepGoing(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"a.go": "package test\nfunc A() {}\n",
+		"b.go": "package test\nfunc B() {}\nthis is not valid go code\n",
+		"c.go": "package test\nfunc C() {}\n",
+	}
+	writeTestFiles(t, tmpDir, files)
+
+	includePrivate = false
+	skipValues = true
+	fileExtensions = ".go"
+	excludeSuffixes = "_test.go"
+	workDir = tmpDir
+	keepGoing = true
+	t.Cleanup(func() {
+		keepGoing = false
+		collectedErrors = nil
+		skipValues, workDir = false, ""
+	})
+
+	var gotErr error
+	output := captureOutput(func() {
+		gotErr = processPaths([]string{tmpDir, filepath.Join(tmpDir, "missing.go")}, token.NewFileSet())
+	})
+	if gotErr != nil {
+		t.Fatalf("unexpected error: %v", gotErr)
+	}
+
+	want := "a.go: func A()\nb.go: func B()\nc.go: func C()"
+	if got := strings.TrimSpace(output); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	if len(collectedErrors) != 2 {
+		t.Fatalf("got %d errors, want 2: %q", len(collectedErrors), collectedErrors)
+	}
+	if want := "b.go:3:1: expected declaration, found this"; collectedErrors[0] != want {
+		t.Errorf("got error %q, want %q", collectedErrors[0], want)
+	}
+	if !strings.Contains(collectedErrors[1], "missing.go") {
+		t.Errorf("got error %q, want an error about missing.go", collectedErrors[1])
+	}
+}
+
+func TestKeepGoingSkipsBadNodes(t *testing.T) {
+	includePrivate = false
+	skipValues = false
+	maxValueLength = 30
+	keepGoing = true
+	t.Cleanup(func() {
+		keepGoing = false
+		collectedErrors = nil
+	})
+
+	filename := createTestFile(t, "package test\n\nfunc A() {}\n\nvar (\n\tGood = 1\n\tBad = )\n)\n\nfunc B(func(((\n\ntype T struct{}\n\nvar V = [\n\nconst K = 1\n")
+
+	output := captureOutput(func() {
+		if err := processFile(filename, token.NewFileSet()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	want := "test.go: func A()\ntest.go: var Good int = 1"
+	if got := strings.TrimSpace(output); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if len(collectedErrors) == 0 {
+		t.Error("parse errors were not recorded")
+	}
+
+	// The parser does not always produce bad nodes: declarations containing
+	// a syntax error are skipped too
+	filename = createTestFile(t, "package test\n\nconst K = 1\n\nfunc Good() {}\n\nfunc Bad( {\n}\n")
+	output = captureOutput(func() {
+		if err := processFile(filename, token.NewFileSet()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	want = "test.go: const K = 1\ntest.go: func Good()"
+	if got := strings.TrimSpace(output); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"go/ast"
+	"strconv"
+	"strings"
+)
+
+// Only list the exported fields of exported struct types, with -exported-fields
+var exportedFieldsOnly bool
+
+// Field is a single field of a struct type, listed in the structured output
+// of struct type declarations. Fields declared together (A, B int) are listed
+// separately.
+type Field struct {
+	Name     string            `json:"name"`
+	Type     string            `json:"type"`
+	Embedded bool              `json:"embedded,omitempty"`
+	Exported bool              `json:"exported"`
+	Tag      string            `json:"tag,omitempty"`
+	Tags     map[string]string `json:"tags,omitempty"`
+	Doc      string            `json:"doc,omitempty"`
+}
+
+// List the fields of a struct type declaration, or nil for other types
+func fieldsOf(spec *ast.TypeSpec) []*Field {
+	st, ok := spec.Type.(*ast.StructType)
+	if !ok || st.Fields == nil {
+		return nil
+	}
+
+	var fields []*Field
+	for _, field := range visibleFields(spec.Name, st.Fields.List) {
+		typeStr := formatType(field.Type)
+		tag := fieldTag(field)
+		doc := docText(field.Doc, field.Comment)
+
+		if len(field.Names) == 0 {
+			name := embeddedName(field.Type)
+			fields = append(fields, &Field{
+				Name:     name,
+				Type:     typeStr,
+				Embedded: true,
+				Exported: ast.IsExported(name),
+				Tag:      tag,
+				Tags:     parseStructTag(tag),
+				Doc:      doc,
+			})
+			continue
+		}
+		for _, name := range field.Names {
+			fields = append(fields, &Field{
+				Name:     name.Name,
+				Type:     typeStr,
+				Exported: name.IsExported(),
+				Tag:      tag,
+				Tags:     parseStructTag(tag),
+				Doc:      doc,
+			})
+		}
+	}
+	return fields
+}
+
+// Filter the fields of a struct type with -exported-fields: unexported names
+// are dropped from the fields of exported types, and fields left without
+// names are dropped entirely
+func visibleFields(typeName *ast.Ident, list []*ast.Field) []*ast.Field {
+	if !exportedFieldsOnly || !typeName.IsExported() {
+		return list
+	}
+	var fields []*ast.Field
+	for _, field := range list {
+		if len(field.Names) == 0 {
+			if ast.IsExported(embeddedName(field.Type)) {
+				fields = append(fields, field)
+			}
+			continue
+		}
+		var names []*ast.Ident
+		for _, name := range field.Names {
+			if name.IsExported() {
+				names = append(names, name)
+			}
+		}
+		if len(names) == 0 {
+			continue
+		}
+		if len(names) < len(field.Names) {
+			copied := *field
+			copied.Names = names
+			field = &copied
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// Format a struct field with all its names, its type and its tag
+func formatStructField(field *ast.Field, typeStr string) string {
+	var buf strings.Builder
+	for i, name := range field.Names {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(name.Name)
+	}
+	if len(field.Names) > 0 {
+		buf.WriteString(" ")
+	}
+	buf.WriteString(typeStr)
+	if field.Tag != nil {
+		buf.WriteString(" ")
+		buf.WriteString(field.Tag.Value)
+	}
+	return buf.String()
+}
+
+// Helper function to get the unquoted tag of a struct field
+func fieldTag(field *ast.Field) string {
+	if field.Tag == nil {
+		return ""
+	}
+	tag, err := strconv.Unquote(field.Tag.Value)
+	if err != nil {
+		return ""
+	}
+	return tag
+}
+
+// Helper function to get the field name of an embedded type: Base for
+// Base, *Base, pkg.Base or Base[T]
+func embeddedName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.StarExpr:
+		return embeddedName(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	case *ast.IndexExpr:
+		return embeddedName(t.X)
+	case *ast.IndexListExpr:
+		return embeddedName(t.X)
+	case *ast.ParenExpr:
+		return embeddedName(t.X)
+	}
+	return ""
+}
+
+// Parse a struct tag into its key:"value" pairs, following the conventions
+// of reflect.StructTag. Parsing stops at the first malformed pair.
+func parseStructTag(tag string) map[string]string {
+	var pairs map[string]string
+	for tag != "" {
+		// Skip leading space
+		tag = strings.TrimLeft(tag, " ")
+		if tag == "" {
+			break
+		}
+
+		// Scan to colon: the key is a non-empty run of non-control,
+		// non-space, non-quote characters
+		i := 0
+		for i < len(tag) && tag[i] > ' ' && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
+			i++
+		}
+		if i == 0 || i+1 >= len(tag) || tag[i] != ':' || tag[i+1] != '"' {
+			break
+		}
+		key := tag[:i]
+		tag = tag[i+1:]
+
+		// Scan quoted string to find the value
+		i = 1
+		for i < len(tag) && tag[i] != '"' {
+			if tag[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(tag) {
+			break
+		}
+		value, err := strconv.Unquote(tag[:i+1])
+		if err != nil {
+			break
+		}
+		tag = tag[i+1:]
+
+		if pairs == nil {
+			pairs = make(map[string]string)
+		}
+		pairs[key] = value
+	}
+	return pairs
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"go/token"
+	"reflect"
+	"testing"
+)
+
+func TestStructFields(t *testing.T) {
+	includePrivate = false
+	skipValues = true
+	t.Cleanup(func() { exportedFieldsOnly, skipValues = false, false })
+
+	filename := createTestFile(t, "package test\n"+
+		"type User struct {\n"+
+		"\tBase\n"+
+		"\tID, OrgID int64 `json:\"id\" db:\"id,pk\"`\n"+
+		"\tName string `json:\"name,omitempty\"` // display name\n"+
+		"\tpassword, Email string\n"+
+		"\tcache map[string]int\n"+
+		"}\n")
+
+	tests := []struct {
+		name           string
+		exportedFields bool
+		signature      string
+		fields         []string
+	}{
+		{
+			name:      "all fields",
+			signature: "type User struct { Base; ID, OrgID int64 `json:\"id\" db:\"id,pk\"`; Name string `json:\"name,omitempty\"`; password, Email string; cache map[string]int }",
+			fields:    []string{"Base", "ID", "OrgID", "Name", "password", "Email", "cache"},
+		},
+		{
+			name:           "exported fields only",
+			exportedFields: true,
+			signature:      "type User struct { Base; ID, OrgID int64 `json:\"id\" db:\"id,pk\"`; Name string `json:\"name,omitempty\"`; Email string }",
+			fields:         []string{"Base", "ID", "OrgID", "Name", "Email"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exportedFieldsOnly = tt.exportedFields
+			decls, err := extractDecls(filename, nil, token.NewFileSet())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(decls) != 1 {
+				t.Fatalf("got %d declarations, want 1", len(decls))
+			}
+			d := decls[0]
+			if d.Signature != tt.signature {
+				t.Errorf("signature:\ngot:  %s\nwant: %s", d.Signature, tt.signature)
+			}
+			var names []string
+			for _, f := range d.Fields {
+				names = append(names, f.Name)
+			}
+			if !reflect.DeepEqual(names, tt.fields) {
+				t.Errorf("fields = %v, want %v", names, tt.fields)
+			}
+		})
+	}
+
+	exportedFieldsOnly = false
+	decls, err := extractDecls(filename, nil, token.NewFileSet())
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := decls[0].Fields
+	if !fields[0].Embedded || !fields[0].Exported {
+		t.Errorf("Base: got %+v, want an exported embedded field", fields[0])
+	}
+	want := map[string]string{"json": "id", "db": "id,pk"}
+	if !reflect.DeepEqual(fields[2].Tags, want) {
+		t.Errorf("OrgID tags = %v, want %v", fields[2].Tags, want)
+	}
+	if fields[3].Doc != "display name" {
+		t.Errorf("Name doc = %q, want %q", fields[3].Doc, "display name")
+	}
+}
+
+func TestParseStructTag(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want map[string]string
+	}{
+		{``, nil},
+		{`json:"id"`, map[string]string{"json": "id"}},
+		{`json:"name,omitempty"  db:"name" validate:"required,max=64"`, map[string]string{"json": "name,omitempty", "db": "name", "validate": "required,max=64"}},
+		{`json:"a\"b"`, map[string]string{"json": `a"b`}},
+		{`json:"id" malformed`, map[string]string{"json": "id"}},
+		{`not a tag`, nil},
+	}
+	for _, tt := range tests {
+		if got := parseStructTag(tt.tag); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseStructTag(%q) = %v, want %v", tt.tag, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+var (
+	matchPattern   string
+	kindFilter     string
+	receiverFilter string
+	sigQuery       string
+
+	// Compiled filters, nil when no filter is set
+	filters *declFilter
+)
+
+// Kinds accepted by -kind, in addition to the declaration kinds
+const (
+	kindInterface = "interface"
+	kindStruct    = "struct"
+)
+
+// declFilter selects declarations by name, kind, receiver and signature
+type declFilter struct {
+	match    func(name string) bool
+	kinds    map[string]bool
+	receiver string
+	sig      *sigPattern
+}
+
+// Compile the -match, -kind, -receiver and -sig flags
+func compileFilters() error {
+	if matchPattern == "" && kindFilter == "" && receiverFilter == "" && sigQuery == "" {
+		filters = nil
+		return nil
+	}
+	f := &declFilter{receiver: receiverFilter}
+
+	if matchPattern != "" {
+		if len(matchPattern) > 1 && strings.HasPrefix(matchPattern, "/") && strings.HasSuffix(matchPattern, "/") {
+			// Regular expression: /pattern/
+			re, err := regexp.Compile(matchPattern[1 : len(matchPattern)-1])
+			if err != nil {
+				return fmt.Errorf("invalid -match regexp: %v", err)
+			}
+			f.match = re.MatchString
+		} else {
+			// Glob pattern
+			if _, err := path.Match(matchPattern, ""); err != nil {
+				return fmt.Errorf("invalid -match pattern: %v", err)
+			}
+			f.match = func(name string) bool {
+				ok, _ := path.Match(matchPattern, name)
+				return ok
+			}
+		}
+	}
+
+	if kindFilter != "" {
+		f.kinds = make(map[string]bool)
+		for _, kind := range strings.Split(kindFilter, ",") {
+			kind = strings.TrimSpace(kind)
+			switch kind {
+			case KindFunc, KindMethod, KindType, KindConst, KindVar, kindInterface, kindStruct:
+				f.kinds[kind] = true
+			default:
+				return fmt.Errorf("unknown -kind: %s", kind)
+			}
+		}
+	}
+
+	if sigQuery != "" {
+		sig, err := parseSigPattern(sigQuery)
+		if err != nil {
+			return err
+		}
+		f.sig = sig
+	}
+
+	filters = f
+	return nil
+}
+
+// Keep only the declarations selected by the filters
+func filterDecls(decls []*Decl) []*Decl {
+	if filters == nil {
+		return decls
+	}
+	kept := decls[:0]
+	for _, d := range decls {
+		if filters.matches(d) {
+			kept = append(kept, d)
+		}
+	}
+	return kept
+}
+
+// Check whether a declaration is selected by the filter
+func (f *declFilter) matches(d *Decl) bool {
+	// Names are matched both alone and qualified by the receiver type
+	if f.match != nil && !f.match(d.Name) && !(d.Receiver != "" && f.match(qualifiedName(d))) {
+		return false
+	}
+
+	if f.kinds != nil && !f.kinds[d.Kind] && !(d.Kind == KindType && f.kinds[d.typeKind]) {
+		return false
+	}
+
+	if f.receiver != "" {
+		if d.Receiver == "" || receiverBase(d.Receiver) != receiverBase(f.receiver) {
+			return false
+		}
+		// -receiver=*T only selects pointer receivers
+		if strings.HasPrefix(f.receiver, "*") && !strings.HasPrefix(d.Receiver, "*") {
+			return false
+		}
+	}
+
+	// -sig only selects functions and methods
+	if f.sig != nil && (d.funcDecl == nil || !f.sig.matches(d.funcDecl)) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func TestFilters(t *testing.T) {
+	code := `package test
+		type Client struct{}
+		type Reader interface{ Read() }
+		type ID string
+		func NewClient() *Client { return nil }
+		func (c *Client) Do() error { return nil }
+		func (c Client) String() string { return "" }
+		func (s *Server) Start() error { return nil }
+		const MaxRetries = 3
+		var DefaultClient = NewClient()`
+
+	tests := []struct {
+		name     string
+		match    string
+		kind     string
+		receiver string
+		want     []string
+		wantErr  bool
+	}{
+		{
+			name:  "glob on name",
+			match: "*Client",
+			want: []string{
+				"type Client struct { }",
+				"func NewClient() *Client",
+				"var DefaultClient",
+			},
+		},
+		{
+			name:  "glob on Type.Method",
+			match: "Client.*",
+			want: []string{
+				"func (*Client) Do() error",
+				"func (Client) String() string",
+			},
+		},
+		{
+			name:  "regexp",
+			match: "/^(Do|Start)$/",
+			want: []string{
+				"func (*Client) Do() error",
+				"func (*Server) Start() error",
+			},
+		},
+		{
+			name: "kinds",
+			kind: "interface,const",
+			want: []string{
+				"type Reader interface { Read() }",
+				"const MaxRetries = 3",
+			},
+		},
+		{
+			name: "struct kind",
+			kind: "struct",
+			want: []string{
+				"type Client struct { }",
+			},
+		},
+		{
+			name:     "receiver",
+			receiver: "Client",
+			want: []string{
+				"func (*Client) Do() error",
+				"func (Client) String() string",
+			},
+		},
+		{
+			name:     "pointer receiver",
+			receiver: "*Client",
+			want: []string{
+				"func (*Client) Do() error",
+			},
+		},
+		{
+			name:  "combined filters",
+			match: "S*",
+			kind:  "method",
+			want: []string{
+				"func (Client) String() string",
+				"func (*Server) Start() error",
+			},
+		},
+		{
+			name:    "unknown kind",
+			kind:    "module",
+			wantErr: true,
+		},
+		{
+			name:    "invalid regexp",
+			match:   "/(/",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			includePrivate = false
+			skipValues = true
+			maxValueLength = 30
+			matchPattern, kindFilter, receiverFilter = tt.match, tt.kind, tt.receiver
+			t.Cleanup(func() {
+				matchPattern, kindFilter, receiverFilter = "", "", ""
+				filters = nil
+				skipValues = false
+			})
+
+			err := compileFilters()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("compileFilters() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			filename := createTestFile(t, code)
+			output := captureOutput(func() {
+				if err := processFile(filename, token.NewFileSet()); err != nil {
+					t.Fatal(err)
+				}
+			})
+
+			var got []string
+			for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+				got = append(got, strings.TrimPrefix(line, "test.go: "))
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Run a git command in the working directory and return its output
+func gitOutput(args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = workDir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// List the files of a revision under the given paths, relative to the working directory
+func gitListFiles(rev string, paths []string) ([]string, error) {
+	args := append([]string{"ls-tree", "-r", "-z", "--name-only", rev, "--"}, paths...)
+	out, err := gitOutput(args...)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, name := range strings.Split(string(out), "\x00") {
+		if name != "" {
+			files = append(files, filepath.FromSlash(name))
+		}
+	}
+	return files, nil
+}
+
+// Read the content of a file at a given revision
+func gitReadFile(rev, path string) ([]byte, error) {
+	return gitOutput("show", rev+":./"+filepath.ToSlash(path))
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// gitignoreRule is a single pattern of a .gitignore file
+type gitignoreRule struct {
+	re      *regexp.Regexp
+	negate  bool // "!pattern" re-includes a previously ignored path
+	dirOnly bool // "pattern/" only matches directories
+}
+
+// gitignore holds the rules of a .gitignore file, relative to its directory
+type gitignore struct {
+	dir   string
+	rules []gitignoreRule
+}
+
+// gitignoreMatcher collects the .gitignore files found while walking
+type gitignoreMatcher struct {
+	ignores []*gitignore // parents before children
+}
+
+// Parse the content of a .gitignore file located in dir
+func parseGitignore(dir, content string) *gitignore {
+	g := &gitignore{dir: dir}
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// Trailing spaces are ignored unless escaped
+		if !strings.HasSuffix(line, "\\ ") {
+			line = strings.TrimRight(line, " ")
+		}
+
+		var rule gitignoreRule
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, "\\!") || strings.HasPrefix(line, "\\#") {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+
+		re, err := regexp.Compile(gitignorePatternToRegexp(line))
+		if err != nil {
+			continue
+		}
+		rule.re = re
+		g.rules = append(g.rules, rule)
+	}
+	return g
+}
+
+// Convert a .gitignore pattern to a regular expression matching slash
+// separated paths relative to the .gitignore directory. Patterns without a
+// slash match at any depth, others are anchored to the directory.
+func gitignorePatternToRegexp(pattern string) string {
+	var buf strings.Builder
+	buf.WriteString("^")
+	if !strings.Contains(pattern, "/") {
+		buf.WriteString("(?:.*/)?")
+	}
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			// Zero or more directories
+			buf.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "/**") && i+3 == len(pattern):
+			// Everything inside
+			buf.WriteString("/.*")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			buf.WriteString(".*")
+			i++
+		case c == '*':
+			buf.WriteString("[^/]*")
+		case c == '?':
+			buf.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				buf.WriteString(regexp.QuoteMeta(string(c)))
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			buf.WriteString("[" + class + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			buf.WriteString(regexp.QuoteMeta(string(pattern[i])))
+		default:
+			buf.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	buf.WriteString("$")
+	return buf.String()
+}
+
+// Check a path against the rules of the file. The last matching rule wins.
+func (g *gitignore) match(path string, isDir bool) (matched, ignored bool) {
+	rel, err := filepath.Rel(g.dir, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return false, false
+	}
+	rel = filepath.ToSlash(rel)
+	for _, rule := range g.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if rule.re.MatchString(rel) {
+			matched, ignored = true, !rule.negate
+		}
+	}
+	return matched, ignored
+}
+
+// Load the .gitignore file of a directory, if any
+func (m *gitignoreMatcher) load(dir string) {
+	content, err := os.ReadFile(filepath.Join(dir, ".gitignore"))
+	if err != nil {
+		return
+	}
+	m.ignores = append(m.ignores, parseGitignore(dir, string(content)))
+}
+
+// Check whether a path is ignored by the loaded .gitignore files, deeper
+// files taking precedence over their parents
+func (m *gitignoreMatcher) ignored(path string, isDir bool) bool {
+	ignored := false
+	for _, g := range m.ignores {
+		if matched, ig := g.match(path, isDir); matched {
+			ignored = ig
+		}
+	}
+	return ignored
+}
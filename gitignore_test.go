@@ -0,0 +1,133 @@
+package main
+
+import (
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func TestGitignoreMatch(t *testing.T) {
+	g := parseGitignore("/repo", `# comment
+*.gen.go
+/build/
+docs/**/*.go
+!keep.gen.go
+tmp[0-9]
+\#notes
+internal/mocks
+`)
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"/repo/api.go", false, false},
+		{"/repo/api.gen.go", false, true},
+		{"/repo/pkg/types.gen.go", false, true},
+		{"/repo/pkg/keep.gen.go", false, false},
+		{"/repo/build", true, true},
+		{"/repo/build", false, false},
+		{"/repo/pkg/build", true, false},
+		{"/repo/docs/example.go", false, true},
+		{"/repo/docs/a/b/example.go", false, true},
+		{"/repo/tmp1", true, true},
+		{"/repo/tmpx", true, false},
+		{"/repo/#notes", false, true},
+		{"/repo/internal/mocks", true, true},
+		{"/repo/pkg/internal/mocks", true, false},
+		{"/other/api.gen.go", false, false},
+	}
+	for _, tt := range tests {
+		if _, got := g.match(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("match(%q, %v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestExcludedDirs(t *testing.T) {
+	files := map[string]string{
+		".gitignore":               "*_string.go\n/generated/\n",
+		"api.go":                   "package a\nfunc API() {}\n",
+		"kind_string.go":           "package a\nfunc (Kind) String() string { return \"\" }\n",
+		"vendor/dep/dep.go":        "package dep\nfunc Vendored() {}\n",
+		"testdata/fixture.go":      "package fixture\nfunc Fixture() {}\n",
+		".hidden/hidden.go":        "package hidden\nfunc Hidden() {}\n",
+		"_old/old.go":              "package old\nfunc Old() {}\n",
+		"node_modules/x/x.go":      "package x\nfunc Module() {}\n",
+		"generated/gen.go":         "package generated\nfunc Generated() {}\n",
+		"sub/.gitignore":           "local.go\n",
+		"sub/local.go":             "package sub\nfunc Local() {}\n",
+		"sub/sub.go":               "package sub\nfunc Sub() {}\n",
+		"sub/mocks/mock.go":        "package mocks\nfunc Mock() {}\n",
+		"sub/generated/notroot.go": "package generated\nfunc NotRoot() {}\n",
+	}
+
+	tests := []struct {
+		name        string
+		excludeDirs string
+		gitignore   bool
+		want        []string
+	}{
+		{
+			name:        "default rules",
+			excludeDirs: "node_modules",
+			want: []string{
+				"api.go: func API()",
+				"generated/gen.go: func Generated()",
+				"kind_string.go: func (Kind) String() string",
+				"sub/generated/notroot.go: func NotRoot()",
+				"sub/local.go: func Local()",
+				"sub/mocks/mock.go: func Mock()",
+				"sub/sub.go: func Sub()",
+			},
+		},
+		{
+			name:        "exclude-dir globs",
+			excludeDirs: "node_modules,sub/mock*,gen*",
+			want: []string{
+				"api.go: func API()",
+				"kind_string.go: func (Kind) String() string",
+				"sub/local.go: func Local()",
+				"sub/sub.go: func Sub()",
+			},
+		},
+		{
+			name:        "gitignore",
+			excludeDirs: "node_modules",
+			gitignore:   true,
+			want: []string{
+				"api.go: func API()",
+				"sub/generated/notroot.go: func NotRoot()",
+				"sub/mocks/mock.go: func Mock()",
+				"sub/sub.go: func Sub()",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			workDir = tmpDir
+			writeTestFiles(t, tmpDir, files)
+
+			includePrivate = false
+			fileExtensions = ".go"
+			excludeSuffixes = "_test.go"
+			excludeDirPatterns, useGitignore = tt.excludeDirs, tt.gitignore
+			t.Cleanup(func() {
+				excludeDirPatterns, useGitignore = "", false
+				workDir = ""
+			})
+
+			output := captureOutput(func() {
+				if err := processPaths([]string{tmpDir}, token.NewFileSet()); err != nil {
+					t.Fatal(err)
+				}
+			})
+			if got := strings.TrimSpace(output); got != strings.Join(tt.want, "\n") {
+				t.Errorf("got:\n%s\nwant:\n%s", got, strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
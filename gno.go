@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bufio"
+	"go/ast"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Gno package kinds
+const (
+	gnoRealm = "realm"
+	gnoPure  = "pure"
+)
+
+// gnoPackage describes the Gno package a directory belongs to
+type gnoPackage struct {
+	path string // import path, e.g. gno.land/r/demo/boards
+	kind string // gnoRealm, gnoPure, or empty when unknown
+}
+
+var (
+	// Cache of Gno packages by absolute directory
+	gnoPackages   = make(map[string]*gnoPackage)
+	gnoPackagesMu sync.Mutex
+)
+
+// Helper function to check if a file is a Gno source file
+func isGnoFile(filename string) bool {
+	return strings.HasSuffix(filename, ".gno")
+}
+
+// Find the Gno package of a directory, from the module declared in the
+// nearest gno.mod or gnomod.toml, or else from a gno.land/... path segment
+func gnoPackageOf(dir string) *gnoPackage {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return &gnoPackage{}
+	}
+
+	gnoPackagesMu.Lock()
+	defer gnoPackagesMu.Unlock()
+	if pkg, ok := gnoPackages[absDir]; ok {
+		return pkg
+	}
+
+	pkg := &gnoPackage{}
+	for d := absDir; ; d = filepath.Dir(d) {
+		if module := readGnoModule(d); module != "" {
+			rel, _ := filepath.Rel(d, absDir)
+			pkg.path = module
+			if rel != "." {
+				pkg.path += "/" + filepath.ToSlash(rel)
+			}
+			break
+		}
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+	if pkg.path == "" {
+		slashDir := filepath.ToSlash(absDir)
+		if i := strings.Index(slashDir, "gno.land/"); i >= 0 {
+			pkg.path = slashDir[i:]
+		}
+	}
+	pkg.kind = gnoPackageKind(pkg.path)
+
+	gnoPackages[absDir] = pkg
+	return pkg
+}
+
+// Read the module path declared in gno.mod or gnomod.toml in a directory
+func readGnoModule(dir string) string {
+	for _, name := range []string{"gnomod.toml", "gno.mod"} {
+		file, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		defer file.Close()
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if !strings.HasPrefix(line, "module") {
+				continue
+			}
+			// gno.mod: module gno.land/r/demo/foo
+			// gnomod.toml: module = "gno.land/r/demo/foo"
+			module := strings.TrimSpace(strings.TrimPrefix(line, "module"))
+			module = strings.TrimSpace(strings.TrimPrefix(module, "="))
+			return strings.Trim(module, `"`)
+		}
+	}
+	return ""
+}
+
+// Get the kind of a Gno package from its path: realms live under
+// <domain>/r/ and pure packages under <domain>/p/
+func gnoPackageKind(pkgPath string) string {
+	parts := strings.SplitN(pkgPath, "/", 3)
+	if len(parts) < 3 || !strings.Contains(parts[0], ".") {
+		return ""
+	}
+	switch parts[1] {
+	case "r":
+		return gnoRealm
+	case "p":
+		return gnoPure
+	}
+	return ""
+}
+
+// Helper function to format the note of a Gno package kind
+func gnoKindNote(kind string) string {
+	if kind == gnoPure {
+		return "pure package"
+	}
+	return kind
+}
+
+// Annotate the declarations of a Gno file with its package, crossing
+// functions and persistent realm state
+func annotateGnoDecls(decls []*Decl, filename string) {
+	pkg := gnoPackageOf(filepath.Dir(filename))
+	for _, d := range decls {
+		if pkg.path != "" {
+			d.Package = pkg.path
+		}
+		d.PackageKind = pkg.kind
+		if pkg.kind != gnoRealm {
+			continue
+		}
+		switch d.Kind {
+		case KindFunc:
+			d.Crossing = d.Exported && isCrossing(d.funcDecl)
+		case KindVar:
+			d.Persistent = true
+		}
+	}
+}
+
+// Check whether a function crosses into the realm: either it takes the
+// realm as first parameter (cur realm, or a std.Realm-style type), or its
+// body starts with a crossing() call
+func isCrossing(decl *ast.FuncDecl) bool {
+	if decl == nil {
+		return false
+	}
+	if params := decl.Type.Params; params != nil && len(params.List) > 0 {
+		switch t := params.List[0].Type.(type) {
+		case *ast.Ident:
+			if t.Name == "realm" {
+				return true
+			}
+		case *ast.SelectorExpr:
+			if t.Sel.Name == "Realm" {
+				return true
+			}
+		}
+	}
+	if decl.Body != nil && len(decl.Body.List) > 0 {
+		if stmt, ok := decl.Body.List[0].(*ast.ExprStmt); ok {
+			if call, ok := stmt.X.(*ast.CallExpr); ok {
+				if ident, ok := call.Fun.(*ast.Ident); ok && ident.Name == "crossing" && len(call.Args) == 0 {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGnoRealm(t *testing.T) {
+	tmpDir := t.TempDir()
+	workDir = tmpDir
+	files := map[string]string{
+		"boards/gno.mod": "module gno.land/r/demo/boards\n",
+		"boards/boards.gno": `package boards
+
+import "std"
+
+var boards []string
+
+var Count int
+
+func CreateBoard(cur realm, name string) int { return 0 }
+
+func Legacy(name string) {
+	crossing()
+}
+
+func Render(path string) string { return "" }
+
+func OldStyle(rlm std.Realm) {}
+
+func helper(cur realm) {}
+`,
+		"boards/boards_test.gno": "package boards\n\nfunc TestCreateBoard(t *testing.T) {}\n",
+		"boards/z0_filetest.gno": "package main\n\nfunc main() {}\n",
+		"gno.land/p/demo/avl/tree.gno": `package avl
+
+var Zero int
+
+func New(size int) int { return 0 }
+`,
+	}
+	writeTestFiles(t, tmpDir, files)
+
+	includePrivate = false
+	skipValues = true
+	maxValueLength = 30
+	fileExtensions = ".gno"
+	excludeSuffixes = "_test.go,_test.gno,_filetest.gno"
+	t.Cleanup(func() { fileExtensions, skipValues, workDir = ".go", false, "" })
+
+	output := captureOutput(func() {
+		if err := processPaths([]string{tmpDir}, token.NewFileSet()); err != nil {
+			t.Fatal(err)
+		}
+	})
+	want := []string{
+		"boards/boards.gno: var Count int // realm; realm state",
+		"boards/boards.gno: func CreateBoard(cur realm, name string) int // realm; crossing",
+		"boards/boards.gno: func Legacy(name string) // realm; crossing",
+		"boards/boards.gno: func Render(path string) string // realm",
+		"boards/boards.gno: func OldStyle(rlm std.Realm) // realm; crossing",
+		"gno.land/p/demo/avl/tree.gno: var Zero int // pure package",
+		"gno.land/p/demo/avl/tree.gno: func New(size int) int // pure package",
+	}
+	if got := strings.TrimSpace(output); got != strings.Join(want, "\n") {
+		t.Errorf("got:\n%s\nwant:\n%s", got, strings.Join(want, "\n"))
+	}
+
+	// Package paths and kinds
+	for _, tt := range []struct {
+		file, pkg, kind string
+	}{
+		{"boards/boards.gno", "gno.land/r/demo/boards", gnoRealm},
+		{"gno.land/p/demo/avl/tree.gno", "gno.land/p/demo/avl", gnoPure},
+	} {
+		decls, err := extractDecls(filepath.Join(tmpDir, tt.file), nil, token.NewFileSet())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, d := range decls {
+			if d.Package != tt.pkg || d.PackageKind != tt.kind {
+				t.Errorf("%s: %s: got package %q (%s), want %q (%s)", tt.file, d.Name, d.Package, d.PackageKind, tt.pkg, tt.kind)
+			}
+		}
+	}
+}
+
+func TestGnoPackageOf(t *testing.T) {
+	tmpDir := t.TempDir()
+	realmDir := filepath.Join(tmpDir, "realm", "sub")
+	pureDir := filepath.Join(tmpDir, "examples", "gno.land", "p", "demo", "avl")
+	for _, dir := range []string{realmDir, pureDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	toml := "# comment\nmodule = \"gno.land/r/demo/users\"\ngno = \"0.9\"\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, "realm", "gnomod.toml"), []byte(toml), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		dir  string
+		path string
+		kind string
+	}{
+		{realmDir, "gno.land/r/demo/users/sub", gnoRealm},
+		{pureDir, "gno.land/p/demo/avl", gnoPure},
+		{tmpDir, "", ""},
+	}
+	for _, tt := range tests {
+		pkg := gnoPackageOf(tt.dir)
+		if pkg.path != tt.path || pkg.kind != tt.kind {
+			t.Errorf("gnoPackageOf(%s) = %q (%s), want %q (%s)", tt.dir, pkg.path, pkg.kind, tt.path, tt.kind)
+		}
+	}
+}
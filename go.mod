@@ -2,4 +2,9 @@ module moul.io/revbro
 
 go 1.23.4
 
-require golang.org/x/tools v0.29.0 // indirect
+require (
+	golang.org/x/mod v0.22.0
+	golang.org/x/tools v0.29.0
+)
+
+require golang.org/x/sync v0.10.0 // indirect
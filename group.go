@@ -0,0 +1,165 @@
+package main
+
+import (
+	"path/filepath"
+	"sort"
+)
+
+// packageGroup holds the declarations of a package organized like go doc:
+// each type with its constructors and methods, then the other declarations
+type packageGroup struct {
+	Path   string
+	Kind   string // Gno package kind, if any
+	Types  []*typeGroup
+	Funcs  []*Decl
+	Consts []*Decl
+	Vars   []*Decl
+}
+
+// typeGroup is a type declaration with its enum constants, constructors and
+// methods. Decl is nil when the type itself is not listed (e.g. an unexported
+// receiver type).
+type typeGroup struct {
+	Name         string
+	Decl         *Decl
+	Consts       []*Decl
+	Constructors []*Decl
+	Methods      []*Decl
+}
+
+// Helper function to get the package of a declaration: its import path when
+// known, otherwise the directory of its file
+func declPackage(d *Decl) string {
+	if d.Package != "" {
+		return d.Package
+	}
+	return filepath.ToSlash(filepath.Dir(d.File))
+}
+
+// Organize declarations by package, in order of first appearance
+func groupDecls(decls []*Decl) []*packageGroup {
+	var pkgs []*packageGroup
+	byPath := make(map[string][]*Decl)
+	for _, d := range decls {
+		path := declPackage(d)
+		if _, ok := byPath[path]; !ok {
+			pkgs = append(pkgs, &packageGroup{Path: path, Kind: d.PackageKind})
+		}
+		byPath[path] = append(byPath[path], d)
+	}
+	for _, pkg := range pkgs {
+		pkg.add(byPath[pkg.Path])
+	}
+	return pkgs
+}
+
+// Add the declarations of a single package to the group
+func (pkg *packageGroup) add(decls []*Decl) {
+	typesByName := make(map[string]*typeGroup)
+	typeGroupFor := func(name string) *typeGroup {
+		tg, ok := typesByName[name]
+		if !ok {
+			tg = &typeGroup{Name: name}
+			typesByName[name] = tg
+			pkg.Types = append(pkg.Types, tg)
+		}
+		return tg
+	}
+
+	// Types first, so that constructors can be attached to them
+	for _, d := range decls {
+		if d.Kind == KindType {
+			typeGroupFor(d.Name).Decl = d
+		}
+	}
+
+	for _, d := range decls {
+		switch d.Kind {
+		case KindMethod:
+			tg := typeGroupFor(receiverBase(d.Receiver))
+			tg.Methods = append(tg.Methods, d)
+		case KindFunc:
+			if tg := constructorOf(d, typesByName); tg != nil {
+				tg.Constructors = append(tg.Constructors, d)
+			} else {
+				pkg.Funcs = append(pkg.Funcs, d)
+			}
+		case KindConst:
+			if tg, ok := typesByName[d.Enum]; ok && tg.Decl != nil {
+				tg.Consts = append(tg.Consts, d)
+			} else {
+				pkg.Consts = append(pkg.Consts, d)
+			}
+		case KindVar:
+			pkg.Vars = append(pkg.Vars, d)
+		}
+	}
+
+	// Note the members of enum types, kept in source order
+	for _, tg := range pkg.Types {
+		if len(tg.Consts) == 0 {
+			continue
+		}
+		tg.Decl.Members = make([]string, 0, len(tg.Consts))
+		for _, d := range tg.Consts {
+			tg.Decl.Members = append(tg.Decl.Members, d.Name)
+		}
+		tg.Decl.Stringer = hasStringMethod(tg.Decl)
+	}
+
+	// Sort types, functions, constructors and methods by name
+	sort.SliceStable(pkg.Types, func(i, j int) bool {
+		return pkg.Types[i].Name < pkg.Types[j].Name
+	})
+	sortDeclsByName(pkg.Funcs)
+	for _, tg := range pkg.Types {
+		sortDeclsByName(tg.Constructors)
+		sortDeclsByName(tg.Methods)
+	}
+}
+
+// Find the listed type a function constructs: the type of its first result,
+// or the type pointed to by it
+func constructorOf(d *Decl, typesByName map[string]*typeGroup) *typeGroup {
+	if len(d.results) == 0 {
+		return nil
+	}
+	if tg, ok := typesByName[d.results[0]]; ok && tg.Decl != nil {
+		return tg
+	}
+	return nil
+}
+
+func sortDeclsByName(decls []*Decl) {
+	sort.SliceStable(decls, func(i, j int) bool {
+		return decls[i].Name < decls[j].Name
+	})
+}
+
+// List the declarations of a package in grouped order: each type followed by
+// its enum constants, constructors and methods, then free functions,
+// constants and variables
+func (pkg *packageGroup) flatten() []*Decl {
+	var decls []*Decl
+	for _, tg := range pkg.Types {
+		if tg.Decl != nil {
+			decls = append(decls, tg.Decl)
+		}
+		decls = append(decls, tg.Consts...)
+		decls = append(decls, tg.Constructors...)
+		decls = append(decls, tg.Methods...)
+	}
+	decls = append(decls, pkg.Funcs...)
+	decls = append(decls, pkg.Consts...)
+	decls = append(decls, pkg.Vars...)
+	return decls
+}
+
+// List the declarations of all packages in grouped order
+func flattenGroups(pkgs []*packageGroup) []*Decl {
+	var decls []*Decl
+	for _, pkg := range pkgs {
+		decls = append(decls, pkg.flatten()...)
+	}
+	return decls
+}
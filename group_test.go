@@ -0,0 +1,63 @@
+package main
+
+import (
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func TestGroupView(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"a/client.go": `package a
+			type Client struct{}
+			func (c *Client) Send() {}
+			func Helper() {}`,
+		"a/config.go": `package a
+			const Version = "1"
+			type Config struct{}
+			func NewClient(cfg Config) *Client { return nil }
+			var Default = Config{}`,
+		"a/methods.go": `package a
+			func (c *Client) Close() error { return nil }
+			func DefaultConfig() Config { return Config{} }`,
+		"b/b.go": `package b
+			func B() {}`,
+	}
+	writeTestFiles(t, tmpDir, files)
+
+	includePrivate = false
+	skipValues = true
+	maxValueLength = 30
+	fileExtensions = ".go"
+	excludeSuffixes = "_test.go"
+	workDir = tmpDir
+	groupView = true
+	t.Cleanup(func() { groupView, skipValues, workDir = false, false, "" })
+
+	output := captureOutput(func() {
+		if err := processPath(tmpDir, token.NewFileSet()); err != nil {
+			t.Fatal(err)
+		}
+		if err := flushDecls(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	want := strings.Join([]string{
+		"a/client.go: type Client struct { }",
+		"a/config.go: func NewClient(cfg Config) *Client",
+		"a/methods.go: func (*Client) Close() error",
+		"a/client.go: func (*Client) Send()",
+		"a/config.go: type Config struct { }",
+		"a/methods.go: func DefaultConfig() Config",
+		"a/client.go: func Helper()",
+		`a/config.go: const Version = "1"`,
+		"a/config.go: var Default Config",
+		"",
+		"b/b.go: func B()",
+	}, "\n")
+	if got := strings.TrimSpace(output); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"go/types"
+	"sort"
+)
+
+// List the interfaces implemented by each type, and the implementations of
+// each interface, with -implements
+var implementsMode bool
+
+// Note on each listed type the interfaces it implements, among the listed
+// interfaces and the exported interfaces of the packages imported by the
+// scanned packages, and on each listed interface the types implementing it.
+// Empty and generic interfaces are skipped, as well as generic types.
+func annotateImplements(decls []*Decl) {
+	type iface struct {
+		obj  *types.TypeName
+		decl *Decl // nil for interfaces of imported packages
+	}
+
+	var ifaces []iface
+	var named []*Decl
+	imported := make(map[*types.Package]bool)
+	for _, d := range decls {
+		obj, ok := d.obj.(*types.TypeName)
+		if !ok || d.Kind != KindType {
+			continue
+		}
+		if isInterfaceCandidate(obj) {
+			ifaces = append(ifaces, iface{obj: obj, decl: d})
+		} else if isImplementationCandidate(obj) {
+			named = append(named, d)
+		}
+		if obj.Pkg() != nil {
+			for _, imp := range obj.Pkg().Imports() {
+				imported[imp] = true
+			}
+		}
+	}
+
+	// Exported interfaces of the imported packages, in a stable order
+	importedPkgs := make([]*types.Package, 0, len(imported))
+	for pkg := range imported {
+		importedPkgs = append(importedPkgs, pkg)
+	}
+	sort.Slice(importedPkgs, func(i, j int) bool {
+		return importedPkgs[i].Path() < importedPkgs[j].Path()
+	})
+	for _, pkg := range importedPkgs {
+		scope := pkg.Scope()
+		for _, name := range scope.Names() {
+			if obj, ok := scope.Lookup(name).(*types.TypeName); ok && obj.Exported() && isInterfaceCandidate(obj) {
+				ifaces = append(ifaces, iface{obj: obj})
+			}
+		}
+	}
+
+	for _, d := range named {
+		d.Implements, d.PointerImplements = nil, nil
+	}
+	for _, i := range ifaces {
+		if i.decl != nil {
+			i.decl.ImplementedBy = nil
+		}
+	}
+
+	for _, d := range named {
+		obj := d.obj.(*types.TypeName)
+		for _, i := range ifaces {
+			it := i.obj.Type().Underlying().(*types.Interface)
+			pointer := false
+			switch {
+			case types.Implements(obj.Type(), it):
+			case types.Implements(types.NewPointer(obj.Type()), it):
+				pointer = true
+			default:
+				continue
+			}
+
+			name := qualifiedTypeName(i.obj, obj.Pkg())
+			if pointer {
+				d.PointerImplements = append(d.PointerImplements, name)
+			} else {
+				d.Implements = append(d.Implements, name)
+			}
+			if i.decl != nil {
+				impl := qualifiedTypeName(obj, i.obj.Pkg())
+				if pointer {
+					impl = "*" + impl
+				}
+				i.decl.ImplementedBy = append(i.decl.ImplementedBy, impl)
+			}
+		}
+	}
+}
+
+// Helper function to check if a type name is a non-empty, non-generic
+// interface that types can be checked against
+func isInterfaceCandidate(obj *types.TypeName) bool {
+	if obj.IsAlias() {
+		return false
+	}
+	named, ok := obj.Type().(*types.Named)
+	if !ok || named.TypeParams().Len() > 0 {
+		return false
+	}
+	it, ok := named.Underlying().(*types.Interface)
+	return ok && it.NumMethods() > 0 && it.IsMethodSet()
+}
+
+// Helper function to check if a type name is a non-generic defined type
+// other than an interface
+func isImplementationCandidate(obj *types.TypeName) bool {
+	if obj.IsAlias() {
+		return false
+	}
+	named, ok := obj.Type().(*types.Named)
+	if !ok || named.TypeParams().Len() > 0 {
+		return false
+	}
+	switch named.Underlying().(type) {
+	case *types.Interface:
+		return false
+	case *types.Basic:
+		return named.Underlying() != types.Typ[types.Invalid]
+	}
+	return true
+}
+
+// Helper function to get the name of a type as seen from a package,
+// qualified by its package name when declared in another package
+func qualifiedTypeName(obj *types.TypeName, from *types.Package) string {
+	if obj.Pkg() == nil || obj.Pkg() == from {
+		return obj.Name()
+	}
+	return obj.Pkg().Name() + "." + obj.Name()
+}
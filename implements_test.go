@@ -0,0 +1,127 @@
+package main
+
+import (
+	"go/token"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestImplements(t *testing.T) {
+	includePrivate = false
+	skipValues = true
+	fileExtensions = ".go"
+	excludeSuffixes = "_test.go"
+	implementsMode = true
+	typeCheck = true
+	t.Cleanup(func() { implementsMode, typeCheck, skipValues = false, false, false })
+
+	filename := createTestFile(t, `package test
+
+import (
+	"fmt"
+	"io"
+)
+
+type Store interface {
+	Get(key string) (string, error)
+}
+
+type Any interface{}
+
+type Client struct{}
+
+func (c *Client) Read(p []byte) (int, error)    { return 0, nil }
+func (c Client) String() string                 { return "" }
+func (c *Client) Get(key string) (string, error) { return "", nil }
+
+type ID int
+
+func (ID) String() string { return "" }
+
+type List[T any] []T
+
+var _ fmt.Stringer = ID(0)
+var _ io.Reader = (*Client)(nil)
+`)
+
+	output := captureOutput(func() {
+		if err := processFile(filename, token.NewFileSet()); err != nil {
+			t.Fatal(err)
+		}
+		if err := flushDecls(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	want := []string{
+		"test.go: type Store interface { Get(key string) (string, error) } // implemented by: *Client",
+		"test.go: type Any interface { }",
+		"test.go: type Client struct { } // implements: fmt.Stringer; pointer implements: Store, io.Reader",
+		"test.go: func (*Client) Read(p []byte) (int, error)",
+		"test.go: func (Client) String() string",
+		"test.go: func (*Client) Get(key string) (string, error)",
+		"test.go: type ID int // implements: fmt.Stringer",
+		"test.go: func (ID) String() string",
+		"test.go: type List[T any] []T",
+	}
+	got := strings.Split(strings.TrimSpace(output), "\n")
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestImplementsModule(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTestFiles(t, tmpDir, map[string]string{
+		"go.mod": "module example.com/m\n\ngo 1.21\n",
+		"store/store.go": `package store
+
+import "context"
+
+type Store interface {
+	Get(ctx context.Context, key string) (string, error)
+}
+`,
+		"mem/mem.go": `package mem
+
+import (
+	"context"
+
+	"example.com/m/store"
+)
+
+type Memory struct{}
+
+func (m *Memory) Get(ctx context.Context, key string) (string, error) { return "", nil }
+
+func New() store.Store { return &Memory{} }
+`,
+	})
+
+	includePrivate = false
+	skipValues = true
+	fileExtensions = ".go"
+	excludeSuffixes = "_test.go"
+	workDir = tmpDir
+	implementsMode = true
+	typeCheck = true
+	t.Cleanup(func() {
+		implementsMode, typeCheck = false, false
+		skipValues, workDir = false, ""
+	})
+
+	output := captureOutput(func() {
+		if err := processPath(filepath.Join(tmpDir, "mem"), token.NewFileSet()); err != nil {
+			t.Fatal(err)
+		}
+		if err := flushDecls(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	want := "mem/mem.go: type Memory struct { } // pointer implements: store.Store"
+	if got := strings.Split(strings.TrimSpace(output), "\n")[0]; got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", output, want)
+	}
+}
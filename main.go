@@ -4,29 +4,35 @@ import (
 	"flag"
 	"fmt"
 	"go/ast"
+	"go/constant"
 	"go/format"
-	"go/importer"
 	"go/parser"
 	"go/token"
 	"go/types"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
 	"strings"
 )
 
 var (
-	includePrivate  bool
-	skipValues      bool
-	maxValueLength  int
-	fileExtensions  string
-	excludeSuffixes string
-	workDir         string
+	includePrivate     bool
+	skipValues         bool
+	noParamNames       bool
+	maxValueLength     int
+	fileExtensions     string
+	excludeSuffixes    string
+	excludeDirPatterns string
+	useGitignore       bool
+	loadPackages       bool
+	typeCheck          bool
+	workDir            string
 )
 
 // Create a type checker configuration
 var conf = types.Config{
-	Importer: importer.Default(),
+	Importer: newSourceImporter(),
 	Error:    func(err error) {}, // Silence errors
 }
 
@@ -47,22 +53,102 @@ func run() error {
 	// Command-line arguments
 	flag.BoolVar(&includePrivate, "private", false, "include private (unexported) declarations")
 	flag.BoolVar(&skipValues, "no-values", false, "skip showing right-hand side values")
+	flag.BoolVar(&noParamNames, "no-param-names", false, "omit parameter and result names from signatures, to compare them across refactors")
 	flag.IntVar(&maxValueLength, "max-length", 30, "maximum length for displayed values before truncating")
 	flag.StringVar(&fileExtensions, "ext", ".go", "comma-separated list of file extensions to process (e.g., .go,.gno)")
-	flag.StringVar(&excludeSuffixes, "exclude", "_test.go", "comma-separated list of file suffixes to exclude (e.g., _test.go,_mock.go)")
-	flag.Parse()
+	flag.StringVar(&excludeSuffixes, "exclude", "_test.go,_test.gno,_filetest.gno", "comma-separated list of file suffixes to exclude (e.g., _test.go,_mock.go)")
+	flag.StringVar(&excludeDirPatterns, "exclude-dir", "node_modules", "comma-separated list of directory globs to skip, in addition to hidden, _-prefixed, testdata and vendor directories")
+	flag.BoolVar(&useGitignore, "gitignore", false, "skip files and directories ignored by .gitignore files found while walking")
+	flag.StringVar(&buildTags, "tags", "", "comma-separated list of build tags to satisfy, in addition to the ones of the platform")
+	flag.StringVar(&buildGOOS, "goos", "", "target operating system for build constraints (default: host)")
+	flag.StringVar(&buildGOARCH, "goarch", "", "target architecture for build constraints (default: host)")
+	flag.BoolVar(&allPlatforms, "all-platforms", false, "list files for all platforms, noting the build constraint of each declaration")
+	flag.BoolVar(&testsMode, "tests", false, "list tests, benchmarks, fuzz targets and examples, then exported declarations that no test references")
+	flag.BoolVar(&loadPackages, "packages", false, "resolve arguments as Go package patterns (e.g., ./..., moul.io/foo/...) using go/packages")
+	flag.BoolVar(&exportedFieldsOnly, "exported-fields", false, "only list the exported fields of exported struct types")
+	flag.BoolVar(&typeCheck, "typecheck", false, "type-check packages and print resolved types and constant values")
+	flag.BoolVar(&implementsMode, "implements", false, "type-check packages and note the interfaces each type implements, and the types implementing each interface")
+	flag.StringVar(&docMode, "doc", "", "include doc comments: \"first\" sentence or \"full\" text")
+	flag.BoolVar(&checkDoc, "check-doc", false, "only list exported declarations without a doc comment, and fail if there are any")
+	flag.BoolVar(&prettyMode, "pretty", false, "print declarations as gofmt-formatted source without function bodies, keeping field and method comments")
+	flag.BoolVar(&showPositions, "pos", false, "prefix declarations with their file:line:column position")
+	flag.BoolVar(&groupView, "group", false, "group declarations per package: types with their constructors and methods, then functions, constants and variables")
+	flag.IntVar(&parallelism, "j", 0, "maximum number of files to parse concurrently (default: number of CPUs)")
+	flag.BoolVar(&keepGoing, "keep-going", false, "continue past parse errors, using partially parsed files, and report all errors at the end")
+	flag.StringVar(&matchPattern, "match", "", "only list declarations whose name (or Type.Method) matches a glob, or a /regexp/")
+	flag.StringVar(&kindFilter, "kind", "", "comma-separated list of kinds to list: func, method, type, const, var, interface, struct")
+	flag.StringVar(&receiverFilter, "receiver", "", "only list methods of a receiver type (e.g., Server, or *Server for pointer receivers only)")
+	flag.StringVar(&sigQuery, "sig", "", "only list functions and methods matching a signature shape (e.g., 'func(context.Context, ...) (*_, error)')")
+	flag.StringVar(&stubDir, "o", "_stub", "output directory of the stub subcommand")
+	flag.StringVar(&outputFormat, "format", formatText, "output format: text, json, jsonl or markdown")
+
+	// Check for a subcommand before parsing flags
+	args := os.Args[1:]
+	command := ""
+	if len(args) > 0 && (args[0] == "diff" || args[0] == "stub") {
+		command, args = args[0], args[1:]
+	}
+	flag.CommandLine.Parse(args)
+
+	switch outputFormat {
+	case formatText, formatJSON, formatJSONL, formatMarkdown:
+	default:
+		return fmt.Errorf("unknown output format: %s", outputFormat)
+	}
+	switch docMode {
+	case "", docFirst, docFull:
+	default:
+		return fmt.Errorf("unknown doc mode: %s", docMode)
+	}
+	if err := compileFilters(); err != nil {
+		return err
+	}
+	if implementsMode {
+		typeCheck = true
+	}
+
+	fset := token.NewFileSet()
+	switch command {
+	case "diff":
+		return runDiff(flag.Args(), fset)
+	case "stub":
+		return runStub(flag.Args(), fset)
+	}
 
 	// Get file paths from arguments
 	paths := flag.Args()
 	if len(paths) == 0 {
 		fmt.Println("Usage: go run main.go [flags] <path1> <path2> ...")
+		fmt.Println("       go run main.go diff [flags] <base> <head> [path...]")
+		fmt.Println("       go run main.go stub [flags] <path1> <path2> ...")
 		fmt.Println("\nPaths can be files, directories, or ./... for recursive scanning")
 		flag.PrintDefaults()
 		return fmt.Errorf("no paths provided")
 	}
 
+	if loadPackages {
+		err = processPackages(paths, fset)
+	} else {
+		err = processPaths(paths, fset)
+	}
+	if err != nil {
+		return err
+	}
+	if err := flushDecls(); err != nil {
+		return err
+	}
+	if err := reportErrors(); err != nil {
+		return err
+	}
+	if checkDoc && undocumentedCount > 0 {
+		return fmt.Errorf("found %d exported declaration(s) without doc comment", undocumentedCount)
+	}
+	return nil
+}
+
+// Process files, directories and ./... recursive paths
+func processPaths(paths []string, fset *token.FileSet) error {
 	// Process each path
-	fset := token.NewFileSet()
 	for _, path := range paths {
 		if strings.HasSuffix(path, "/...") || strings.HasSuffix(path, "\\...") {
 			// Handle recursive path
@@ -74,7 +160,7 @@ func run() error {
 			if err != nil {
 				return fmt.Errorf("invalid path %s: %v", path, err)
 			}
-			if err := processPath(absPath, fset); err != nil {
+			if err := keepGoingOn(processPath(absPath, fset)); err != nil {
 				return err
 			}
 		} else {
@@ -82,7 +168,7 @@ func run() error {
 			if err != nil {
 				return fmt.Errorf("invalid path %s: %v", path, err)
 			}
-			if err := processPath(absPath, fset); err != nil {
+			if err := keepGoingOn(processPath(absPath, fset)); err != nil {
 				return err
 			}
 		}
@@ -90,70 +176,183 @@ func run() error {
 	return nil
 }
 
-// Process a single Go file and extract declarations
+// Process a single Go file and print its declarations
 func processFile(filename string, fset *token.FileSet) error {
-	f, err := parser.ParseFile(fset, filename, nil, parser.ParseComments)
-	if err != nil {
+	decls, err := extractDecls(filename, nil, fset)
+	if err := keepGoingOn(err); err != nil {
 		return err
 	}
+	return emitDecls(decls)
+}
 
-	// Get relative path for output
-	relPath := filename
-	if absPath, err := filepath.Abs(filename); err == nil {
-		if rel, err := filepath.Rel(workDir, absPath); err == nil {
-			relPath = rel
+// Parse a single Go file and extract its declarations in source order.
+// If src is nil, the file is read from disk. In -keep-going mode, the
+// declarations of a partially parsed file are returned with the parse error.
+func extractDecls(filename string, src any, fset *token.FileSet) ([]*Decl, error) {
+	if typeCheck && src == nil {
+		f, cp, err := checkFile(filename, fset)
+		if f == nil || (err != nil && !keepGoing) {
+			return nil, err
+		}
+		return fileDecls(markSyntaxErrors(f, fset, err), filename, fset, cp), err
+	}
+
+	f, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if f == nil || (err != nil && !keepGoing) {
+		return nil, err
+	}
+	return fileDecls(markSyntaxErrors(f, fset, err), filename, fset, nil), err
+}
+
+// Extract the declarations of a parsed file in source order, using type
+// information when the package was type-checked (cp may be nil)
+func fileDecls(f *ast.File, filename string, fset *token.FileSet, cp *checkedPackage) []*Decl {
+	relPath := relativePath(filename)
+
+	var decls []*Decl
+	newDecl := func(kind string, name *ast.Ident, signature string) *Decl {
+		pos := fset.Position(name.Pos())
+		d := &Decl{
+			File:      relPath,
+			Line:      pos.Line,
+			Column:    pos.Column,
+			Kind:      kind,
+			Name:      name.Name,
+			Signature: signature,
+			Exported:  name.IsExported(),
+			pos:       name.Pos(),
 		}
+		decls = append(decls, d)
+		return d
 	}
 
-	// Create a map to store declarations by their position
-	declsByPos := make(map[token.Pos][]string)
+	// Values of the constants of the file, as far as they can be computed
+	knownConsts := make(map[string]constant.Value)
 
 	// Process all declarations in the file
 	for _, decl := range f.Decls {
 		switch d := decl.(type) {
 		case *ast.GenDecl:
+			consts := newConstGroup(d, knownConsts)
 			for _, spec := range d.Specs {
+				if hasBadNode(spec) {
+					continue
+				}
 				switch s := spec.(type) {
 				case *ast.TypeSpec:
 					if !includePrivate && !s.Name.IsExported() {
 						continue
 					}
-					declsByPos[s.Pos()] = []string{fmt.Sprintf("%s: %s", relPath, formatTypeSpec(s))}
+					td := newDecl(KindType, s.Name, formatTypeSpec(s))
+					td.Doc = docText(s.Doc, d.Doc)
+					td.Underlying = cp.underlying(s)
+					if obj := cp.object(s.Name); obj != nil {
+						td.obj = obj
+					}
+					td.Fields = fieldsOf(s)
+					if prettyMode {
+						td.Source = prettyTypeSpec(s, f, fset)
+					}
+					switch s.Type.(type) {
+					case *ast.StructType:
+						td.typeKind = kindStruct
+					case *ast.InterfaceType:
+						td.typeKind = kindInterface
+					}
 				case *ast.ValueSpec:
 					if !includePrivate && !s.Names[0].IsExported() {
 						continue
 					}
-					decls := formatValueSpec(s, d.Tok, maxValueLength)
-					for _, decl := range decls {
-						declsByPos[s.Pos()] = append(declsByPos[s.Pos()], fmt.Sprintf("%s: %s", relPath, decl))
+					kind := KindVar
+					if d.Tok == token.CONST {
+						kind = KindConst
+					}
+					var source string
+					if prettyMode {
+						if spec := consts.standaloneSpec(s); spec == s {
+							source = prettyValueSpec(s, d.Tok, f.Comments, fset)
+						} else if spec != nil {
+							// Computed values have no comments of their own
+							source = prettyValueSpec(spec, d.Tok, nil, fset)
+						}
+					}
+					for i, entry := range valueSpecEntries(consts.spec(s), d.Tok, maxValueLength) {
+						consts.resolveValueSpecEntry(&entry, s, i)
+						cp.resolveValueSpecEntry(&entry, s, i)
+						vd := newDecl(kind, entry.name, entry.String())
+						vd.Doc = docText(s.Doc, d.Doc)
+						vd.Type = entry.typ
+						vd.Value = entry.value
+						vd.Enum = consts.enum
+						vd.Source = source
 					}
 				}
 			}
 		case *ast.FuncDecl:
-			if !includePrivate && !d.Name.IsExported() {
+			if (!includePrivate && !d.Name.IsExported()) || hasBadNode(d) {
 				continue
 			}
-			declsByPos[d.Pos()] = []string{fmt.Sprintf("%s: %s", relPath, formatFuncDecl(d))}
+			fd := newDecl(KindFunc, d.Name, formatFuncDecl(d))
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				fd.Kind = KindMethod
+				fd.Receiver = types.ExprString(d.Recv.List[0].Type)
+			}
+			fd.Doc = docText(d.Doc)
+			fd.funcDecl = d
+			if prettyMode {
+				fd.Source = prettyFuncDecl(d, f, fset)
+			}
+			if d.Type.Results != nil {
+				for _, result := range d.Type.Results.List {
+					fd.results = append(fd.results, receiverBase(types.ExprString(result.Type)))
+				}
+			}
 		}
 	}
 
-	// Get positions in order
-	var positions []token.Pos
-	for pos := range declsByPos {
-		positions = append(positions, pos)
-	}
-	sort.Slice(positions, func(i, j int) bool {
-		return positions[i] < positions[j]
+	// Keep declarations in position order
+	sort.SliceStable(decls, func(i, j int) bool {
+		return decls[i].pos < decls[j].pos
 	})
+	annotateEnums(decls, f)
 
-	// Print declarations in order
-	for _, pos := range positions {
-		for _, decl := range declsByPos[pos] {
-			fmt.Println(decl)
+	if allPlatforms {
+		if expr := fileConstraint(filename, f); expr != nil {
+			for _, d := range decls {
+				d.Constraint = expr.String()
+			}
 		}
 	}
 
-	return nil
+	if testsMode && isTestFile(filename) {
+		decls = testFileDecls(f, filename, fset, decls)
+	}
+
+	if isGnoFile(filename) {
+		annotateGnoDecls(decls, filename)
+	}
+
+	return filterDecls(decls)
+}
+
+// Helper function to get the path of a file relative to the working directory
+func relativePath(filename string) string {
+	if absPath, err := filepath.Abs(filename); err == nil {
+		if rel, err := filepath.Rel(workDir, absPath); err == nil {
+			return rel
+		}
+	}
+	return filename
+}
+
+// Helper function to get the text of the first non-empty doc comment
+func docText(groups ...*ast.CommentGroup) string {
+	for _, group := range groups {
+		if text := strings.TrimSpace(group.Text()); text != "" {
+			return text
+		}
+	}
+	return ""
 }
 
 // Process a file or directory
@@ -163,47 +362,48 @@ func processPath(path string, fset *token.FileSet) error {
 		return fmt.Errorf("error accessing path %s: %v", path, err)
 	}
 
-	// Split extensions into a slice and normalize them
-	extensions := strings.Split(fileExtensions, ",")
-	for i, ext := range extensions {
-		extensions[i] = strings.TrimSpace(ext)
-		if !strings.HasPrefix(extensions[i], ".") {
-			extensions[i] = "." + extensions[i]
-		}
-	}
-
-	// Split exclude suffixes into a slice and normalize them
-	excludes := strings.Split(excludeSuffixes, ",")
-	for i, suffix := range excludes {
-		excludes[i] = strings.TrimSpace(suffix)
-	}
+	extensions := splitExtensions()
+	excludes := splitExcludes()
+	excludeDirs := splitExcludeDirs()
 
 	if fileInfo.IsDir() {
+		root := path
+		ignores := &gitignoreMatcher{}
+
 		// Get all files first
 		var files []string
 		err := filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
 			if err != nil {
-				return err
+				return keepGoingOn(err)
 			}
-			if !info.IsDir() {
-				// Check if file should be excluded based on suffix
-				for _, suffix := range excludes {
-					if strings.HasSuffix(strings.ToLower(path), strings.ToLower(suffix)) {
-						return nil
-					}
+			if useGitignore && path != root && ignores.ignored(path, info.IsDir()) {
+				if info.IsDir() {
+					return filepath.SkipDir
 				}
-
-				// Check if file has any of the specified extensions
-				for _, ext := range extensions {
-					if strings.HasSuffix(strings.ToLower(path), strings.ToLower(ext)) {
-						absPath, err := filepath.Abs(path)
-						if err == nil {
-							files = append(files, absPath)
-						} else {
-							files = append(files, path)
-						}
-						break
-					}
+				return nil
+			}
+			if info.IsDir() {
+				// Skip directories like the go tool does, except the root
+				if path != root && isExcludedDir(path, root, excludeDirs) {
+					return filepath.SkipDir
+				}
+				if useGitignore {
+					ignores.load(path)
+				}
+			} else {
+				// Check if file is excluded or lacks a supported extension
+				if isExcluded(path, excludes) || !hasExtension(path, extensions) {
+					return nil
+				}
+				// Check if file is excluded by build constraints
+				if !fileMatchesBuild(path) {
+					return nil
+				}
+				absPath, err := filepath.Abs(path)
+				if err == nil {
+					files = append(files, absPath)
+				} else {
+					files = append(files, path)
 				}
 			}
 			return nil
@@ -219,94 +419,144 @@ func processPath(path string, fset *token.FileSet) error {
 			return relI < relJ
 		})
 
-		// Process files in sorted order
-		for _, file := range files {
-			if err := processFile(file, fset); err != nil {
-				return err
-			}
-		}
-		return nil
+		// Process files concurrently, printing them in sorted order
+		return extractFiles(files, fset, emitDecls)
 	}
 
 	// Check if single file should be excluded based on suffix
-	for _, suffix := range excludes {
-		if strings.HasSuffix(strings.ToLower(path), strings.ToLower(suffix)) {
-			return nil
-		}
+	if isExcluded(path, excludes) {
+		return nil
 	}
 
 	// Check if single file has any of the specified extensions
-	for _, ext := range extensions {
-		if strings.HasSuffix(strings.ToLower(path), strings.ToLower(ext)) {
-			return processFile(path, fset)
-		}
+	if hasExtension(path, extensions) {
+		return processFile(path, fset)
 	}
 	return fmt.Errorf("file does not have a supported extension (%s): %s", fileExtensions, path)
 }
 
-// Helper function to format a field (struct field or interface method)
-func formatField(field *ast.Field) string {
-	if len(field.Names) == 0 {
-		// For embedded fields (like io.Reader), just return the type
-		return types.ExprString(field.Type)
+// Split the -ext flag into a slice of normalized extensions
+func splitExtensions() []string {
+	extensions := strings.Split(fileExtensions, ",")
+	for i, ext := range extensions {
+		extensions[i] = strings.TrimSpace(ext)
+		if !strings.HasPrefix(extensions[i], ".") {
+			extensions[i] = "." + extensions[i]
+		}
 	}
+	return extensions
+}
 
-	var buf strings.Builder
-	buf.WriteString(field.Names[0].Name)
-
-	if ft, ok := field.Type.(*ast.FuncType); ok {
-		// Format method signature
-		buf.WriteString(formatFuncType(ft))
-	} else {
-		// Format field type
-		buf.WriteString(" ")
-		buf.WriteString(types.ExprString(field.Type))
+// Split the -exclude flag into a slice of normalized suffixes
+func splitExcludes() []string {
+	excludes := strings.Split(excludeSuffixes, ",")
+	for i, suffix := range excludes {
+		excludes[i] = strings.TrimSpace(suffix)
+		// Test files are listed in -tests mode
+		if testsMode && isTestFile(excludes[i]) {
+			excludes[i] = ""
+		}
 	}
+	return excludes
+}
 
-	return buf.String()
+// Split the -exclude-dir flag into a slice of glob patterns
+func splitExcludeDirs() []string {
+	var patterns []string
+	for _, pattern := range strings.Split(excludeDirPatterns, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			patterns = append(patterns, filepath.ToSlash(strings.TrimSuffix(pattern, "/")))
+		}
+	}
+	return patterns
 }
 
-// Helper function to format function type (parameters and results)
-func formatFuncType(ft *ast.FuncType) string {
-	var buf strings.Builder
+// Helper function to check if a directory should be skipped: hidden and
+// _-prefixed directories, testdata and vendor (like the go tool), and
+// directories matching an -exclude-dir glob by name or by path from the root
+func isExcludedDir(path, root string, patterns []string) bool {
+	name := filepath.Base(path)
+	if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "testdata" || name == "vendor" {
+		return true
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		rel = path
+	}
+	rel = filepath.ToSlash(rel)
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, rel); ok {
+			return true
+		}
+	}
+	return false
+}
 
-	// Format parameters
-	buf.WriteString("(")
-	if ft.Params != nil && len(ft.Params.List) > 0 {
-		params := make([]string, 0, len(ft.Params.List))
-		for _, param := range ft.Params.List {
-			params = append(params, formatField(param))
+// Helper function to check if a slash-separated file path is inside a
+// skipped directory
+func inExcludedDir(file string, patterns []string) bool {
+	dir := path.Dir(file)
+	for dir != "." && dir != "/" {
+		if isExcludedDir(dir, ".", patterns) {
+			return true
 		}
-		buf.WriteString(strings.Join(params, ", "))
+		dir = path.Dir(dir)
 	}
-	buf.WriteString(")")
+	return false
+}
 
-	// Format results
-	if ft.Results != nil && len(ft.Results.List) > 0 {
-		buf.WriteString(" ")
-		results := make([]string, 0, len(ft.Results.List))
-		for _, result := range ft.Results.List {
-			typeStr := types.ExprString(result.Type)
-			// Remove parentheses from pointer types
-			if strings.HasPrefix(typeStr, "(*") {
-				typeStr = "*" + typeStr[2:len(typeStr)-1]
-			}
-			results = append(results, typeStr)
+// Helper function to check if a file has one of the given extensions
+func hasExtension(path string, extensions []string) bool {
+	for _, ext := range extensions {
+		if strings.HasSuffix(strings.ToLower(path), strings.ToLower(ext)) {
+			return true
 		}
+	}
+	return false
+}
 
-		// Only add parentheses for multiple results that aren't already parenthesized
-		if len(results) > 1 && !strings.HasPrefix(results[0], "(") {
-			buf.WriteString("(")
-			buf.WriteString(strings.Join(results, ", "))
-			buf.WriteString(")")
-		} else {
-			buf.WriteString(strings.Join(results, ", "))
+// Helper function to check if a file ends with one of the excluded suffixes
+func isExcluded(path string, excludes []string) bool {
+	for _, suffix := range excludes {
+		if suffix != "" && strings.HasSuffix(strings.ToLower(path), strings.ToLower(suffix)) {
+			return true
 		}
 	}
+	return false
+}
 
+// Helper function to format function type (parameters and results)
+func formatFuncType(ft *ast.FuncType) string {
+	var buf strings.Builder
+	buf.WriteString("(")
+	buf.WriteString(formatFieldList(ft.Params))
+	buf.WriteString(")")
+	if results := formatMethodResults(ft.Results); results != "" {
+		buf.WriteString(" ")
+		buf.WriteString(results)
+	}
 	return buf.String()
 }
 
+// Helper function to format a type parameter list with its constraints
+func formatTypeParams(fl *ast.FieldList) string {
+	if fl == nil || len(fl.List) == 0 {
+		return ""
+	}
+	params := make([]string, 0, len(fl.List))
+	for _, field := range fl.List {
+		names := make([]string, 0, len(field.Names))
+		for _, name := range field.Names {
+			names = append(names, name.Name)
+		}
+		params = append(params, strings.Join(names, ", ")+" "+types.ExprString(field.Type))
+	}
+	return "[" + strings.Join(params, ", ") + "]"
+}
+
 // Modified formatValue to handle ast.Expr
 func formatValue(expr ast.Expr, maxLen int) string {
 	switch v := expr.(type) {
@@ -351,20 +601,29 @@ func formatValue(expr ast.Expr, maxLen int) string {
 	}
 }
 
-// Format a field list (parameters or results)
+// Format a field list (parameters or results), keeping names declared
+// together (a, b int) as in the source. With -no-param-names, only the
+// types are listed, once per name.
 func formatFieldList(fl *ast.FieldList) string {
 	if fl == nil {
 		return ""
 	}
 	var parts []string
 	for _, field := range fl.List {
-		typeStr := strings.TrimSpace(strings.ReplaceAll(exprToString(field.Type), "\n", " "))
-		if len(field.Names) > 0 {
+		typeStr := formatTypeExpr(field.Type)
+		switch {
+		case len(field.Names) == 0:
+			parts = append(parts, typeStr)
+		case noParamNames:
+			for range field.Names {
+				parts = append(parts, typeStr)
+			}
+		default:
+			names := make([]string, 0, len(field.Names))
 			for _, name := range field.Names {
-				parts = append(parts, name.Name+" "+typeStr)
+				names = append(names, name.Name)
 			}
-		} else {
-			parts = append(parts, typeStr)
+			parts = append(parts, strings.Join(names, ", ")+" "+typeStr)
 		}
 	}
 	return strings.Join(parts, ", ")
@@ -493,7 +752,7 @@ func processGenDecl(decl *ast.GenDecl, fset *token.FileSet) {
 
 					// Format type with semicolons for struct and interface fields
 					typeStr := formatTypeWithSemicolons(ts.Type)
-					fmt.Printf("%s: type %s %s\n", relPath, ts.Name.Name, typeStr)
+					fmt.Printf("%s: type %s%s %s\n", relPath, ts.Name.Name, formatTypeParams(ts.TypeParams), typeStr)
 				}
 			}
 		}
@@ -601,7 +860,7 @@ func formatMethodResults(fl *ast.FieldList) string {
 	resultStr := formatFieldList(fl)
 
 	// Add parentheses if there are multiple results or named results
-	needParens := len(fl.List) > 1 || (len(fl.List) == 1 && len(fl.List[0].Names) > 0)
+	needParens := fl.NumFields() > 1 || (len(fl.List[0].Names) > 0 && !noParamNames)
 	if needParens {
 		return "(" + resultStr + ")"
 	}
@@ -612,22 +871,22 @@ func formatTypeSpec(spec *ast.TypeSpec) string {
 	var buf strings.Builder
 	buf.WriteString("type ")
 	buf.WriteString(spec.Name.Name)
+	buf.WriteString(formatTypeParams(spec.TypeParams))
 	buf.WriteString(" ")
 
 	switch t := spec.Type.(type) {
 	case *ast.StructType:
 		buf.WriteString("struct { ")
 		if t.Fields != nil {
-			fields := make([]string, 0, len(t.Fields.List))
-			for _, field := range t.Fields.List {
+			list := visibleFields(spec.Name, t.Fields.List)
+			fields := make([]string, 0, len(list))
+			for _, field := range list {
 				if len(field.Names) == 0 {
 					// Handle embedded types
-					fields = append(fields, types.ExprString(field.Type))
+					fields = append(fields, formatStructField(field, types.ExprString(field.Type)))
 				} else {
 					// Handle regular fields
-					fieldName := field.Names[0].Name
-					fieldType := formatType(field.Type)
-					fields = append(fields, fmt.Sprintf("%s %s", fieldName, fieldType))
+					fields = append(fields, formatStructField(field, formatType(field.Type)))
 				}
 			}
 			buf.WriteString(strings.Join(fields, "; "))
@@ -655,7 +914,7 @@ func formatTypeSpec(spec *ast.TypeSpec) string {
 		buf.WriteString(" }")
 
 	default:
-		buf.WriteString(types.ExprString(t))
+		buf.WriteString(formatTypeExpr(t))
 	}
 
 	return strings.ReplaceAll(buf.String(), "  ", " ")
@@ -669,13 +928,7 @@ func formatType(expr ast.Expr) string {
 		if t.Fields != nil {
 			fields := make([]string, 0, len(t.Fields.List))
 			for _, field := range t.Fields.List {
-				if len(field.Names) == 0 {
-					fields = append(fields, types.ExprString(field.Type))
-				} else {
-					fieldName := field.Names[0].Name
-					fieldType := types.ExprString(field.Type)
-					fields = append(fields, fmt.Sprintf("%s %s", fieldName, fieldType))
-				}
+				fields = append(fields, formatStructField(field, formatTypeExpr(field.Type)))
 			}
 			buf.WriteString(strings.Join(fields, "; "))
 		}
@@ -703,75 +956,176 @@ func formatType(expr ast.Expr) string {
 		return buf.String()
 
 	default:
-		return types.ExprString(expr)
+		return formatTypeExpr(expr)
+	}
+}
+
+// Helper function to format a type expression, without the parameter and
+// result names of its function types with -no-param-names
+func formatTypeExpr(expr ast.Expr) string {
+	if noParamNames {
+		expr = stripParamNames(expr)
 	}
+	return types.ExprString(expr)
+}
+
+// Get a copy of a type expression with the parameter and result names of
+// its function types removed, at any depth
+func stripParamNames(expr ast.Expr) ast.Expr {
+	switch t := expr.(type) {
+	case *ast.FuncType:
+		return &ast.FuncType{
+			TypeParams: t.TypeParams,
+			Params:     stripFieldListNames(t.Params),
+			Results:    stripFieldListNames(t.Results),
+		}
+	case *ast.StarExpr:
+		return &ast.StarExpr{X: stripParamNames(t.X)}
+	case *ast.ParenExpr:
+		return &ast.ParenExpr{X: stripParamNames(t.X)}
+	case *ast.Ellipsis:
+		return &ast.Ellipsis{Elt: stripParamNames(t.Elt)}
+	case *ast.ArrayType:
+		return &ast.ArrayType{Len: t.Len, Elt: stripParamNames(t.Elt)}
+	case *ast.MapType:
+		return &ast.MapType{Key: stripParamNames(t.Key), Value: stripParamNames(t.Value)}
+	case *ast.ChanType:
+		return &ast.ChanType{Dir: t.Dir, Value: stripParamNames(t.Value)}
+	case *ast.IndexExpr:
+		return &ast.IndexExpr{X: t.X, Index: stripParamNames(t.Index)}
+	case *ast.IndexListExpr:
+		indices := make([]ast.Expr, 0, len(t.Indices))
+		for _, index := range t.Indices {
+			indices = append(indices, stripParamNames(index))
+		}
+		return &ast.IndexListExpr{X: t.X, Indices: indices}
+	case *ast.StructType:
+		return &ast.StructType{Fields: stripFieldTypes(t.Fields)}
+	case *ast.InterfaceType:
+		return &ast.InterfaceType{Methods: stripFieldTypes(t.Methods)}
+	}
+	return expr
+}
+
+// Get a copy of a parameter or result list with one unnamed field per name
+func stripFieldListNames(fl *ast.FieldList) *ast.FieldList {
+	if fl == nil {
+		return nil
+	}
+	stripped := &ast.FieldList{}
+	for _, field := range fl.List {
+		typ := stripParamNames(field.Type)
+		for i := 0; i < max(len(field.Names), 1); i++ {
+			stripped.List = append(stripped.List, &ast.Field{Type: typ})
+		}
+	}
+	return stripped
+}
+
+// Get a copy of a struct field or interface method list, keeping the names
+// of the fields and methods but not the ones of their parameters
+func stripFieldTypes(fl *ast.FieldList) *ast.FieldList {
+	if fl == nil {
+		return nil
+	}
+	stripped := &ast.FieldList{}
+	for _, field := range fl.List {
+		stripped.List = append(stripped.List, &ast.Field{Names: field.Names, Type: stripParamNames(field.Type), Tag: field.Tag})
+	}
+	return stripped
+}
+
+// valueSpecEntry holds the formatted type and value of a single name in a ValueSpec
+type valueSpecEntry struct {
+	tok   token.Token
+	name  *ast.Ident
+	typ   string
+	value string
+}
+
+func (e valueSpecEntry) String() string {
+	var buf strings.Builder
+	buf.WriteString(e.tok.String())
+	buf.WriteString(" ")
+	buf.WriteString(e.name.Name)
+	// Untyped constant types are not Go syntax, they are only kept in Decl.Type
+	if e.typ != "" && !strings.HasPrefix(e.typ, "untyped ") {
+		buf.WriteString(" ")
+		buf.WriteString(e.typ)
+	}
+	if e.value != "" {
+		buf.WriteString(" = ")
+		buf.WriteString(e.value)
+	}
+	return buf.String()
 }
 
 func formatValueSpec(spec *ast.ValueSpec, tok token.Token, maxLen int) []string {
-	var declarations []string
+	entries := valueSpecEntries(spec, tok, maxLen)
+	declarations := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		declarations = append(declarations, entry.String())
+	}
+	return declarations
+}
+
+func valueSpecEntries(spec *ast.ValueSpec, tok token.Token, maxLen int) []valueSpecEntry {
+	var entries []valueSpecEntry
 	var lastValue ast.Expr
 
 	// Handle multiple names in a single spec
 	for i, name := range spec.Names {
-		var buf strings.Builder
-		buf.WriteString("var ")
-		buf.WriteString(name.Name)
+		entry := valueSpecEntry{tok: tok, name: name}
 
-		// Get or infer type
-		typeStr := ""
+		// Get or infer type; constants without a declared type are untyped
 		if spec.Type != nil {
-			typeStr = types.ExprString(spec.Type)
+			entry.typ = formatTypeExpr(spec.Type)
+		} else if tok == token.CONST {
+			if i < len(spec.Values) {
+				lastValue = spec.Values[i]
+			}
 		} else if i < len(spec.Values) {
-			typeStr = inferType(spec.Values[i])
+			entry.typ = inferType(spec.Values[i])
 			lastValue = spec.Values[i]
 		} else if lastValue != nil {
-			typeStr = inferType(lastValue)
-		}
-
-		// Add type if present
-		if typeStr != "" {
-			buf.WriteString(" ")
-			buf.WriteString(typeStr)
+			entry.typ = inferType(lastValue)
 		}
 
 		// Add value if present and not skipping values
 		if i < len(spec.Values) && !skipValues {
-			buf.WriteString(" = ")
 			if mapLit, ok := spec.Values[i].(*ast.CompositeLit); ok && isMapType(mapLit.Type) {
 				// For map literals, include the type in the value
-				mapType := types.ExprString(mapLit.Type)
-				buf.WriteString(mapType)
-				buf.WriteString(formatMapLiteral(mapLit))
+				entry.value = types.ExprString(mapLit.Type) + formatMapLiteral(mapLit)
 			} else {
-				buf.WriteString(formatValue(spec.Values[i], maxLen))
+				entry.value = formatValue(spec.Values[i], maxLen)
 			}
 			lastValue = spec.Values[i]
 		} else if tok == token.CONST {
 			// For constants without explicit values
 			if lastValue != nil {
 				// Use the last value for subsequent constants in a group
-				buf.WriteString(" = ")
-				buf.WriteString(formatValue(lastValue, maxLen))
+				entry.value = formatValue(lastValue, maxLen)
 			}
 		}
 
-		declarations = append(declarations, buf.String())
+		entries = append(entries, entry)
 	}
 
-	return declarations
+	return entries
 }
 
 func formatFuncDecl(decl *ast.FuncDecl) string {
 	var buf strings.Builder
 	buf.WriteString("func ")
 
-	// Skip receiver in output unless it's a method
-	if decl.Recv == nil {
-		buf.WriteString(decl.Name.Name)
-	} else {
-		// For methods, use the original name without receiver
-		buf.WriteString(decl.Name.Name)
+	// For methods, show the receiver type (without its name)
+	if decl.Recv != nil && len(decl.Recv.List) > 0 {
+		buf.WriteString("(")
+		buf.WriteString(types.ExprString(decl.Recv.List[0].Type))
+		buf.WriteString(") ")
 	}
+	buf.WriteString(decl.Name.Name)
+	buf.WriteString(formatTypeParams(decl.Type.TypeParams))
 
 	// Format parameters and results
 	if ft := decl.Type; ft != nil {
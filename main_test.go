@@ -54,6 +54,7 @@ func createTestFile(t *testing.T, content string) string {
 	t.Helper()
 	tmpDir := t.TempDir()
 	workDir = tmpDir // Set workDir for relative path calculations
+	t.Cleanup(func() { workDir = "" })
 
 	filename := filepath.Join(tmpDir, "test.go")
 	err := os.WriteFile(filename, []byte(content), 0644)
@@ -63,6 +64,25 @@ func createTestFile(t *testing.T, content string) string {
 	return filename
 }
 
+// writeTestFiles writes files by path relative to dir, creating their
+// directories. An empty content removes the file.
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for path, content := range files {
+		fullPath := filepath.Join(dir, path)
+		if content == "" {
+			os.Remove(fullPath)
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
 func TestProcessFile(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -110,9 +130,9 @@ func TestProcessFile(t *testing.T) {
 			includePrivate: true,
 			skipValues:     true,
 			want: []string{
-				"var private int = 1",
-				"var Public int = 2",
-				`var VeryLong string = "this is a very long consta...`,
+				"const private = 1",
+				"const Public = 2",
+				`const VeryLong = "this is a very long consta...`,
 			},
 		},
 		{
@@ -127,10 +147,10 @@ func TestProcessFile(t *testing.T) {
 			includePrivate: true,
 			skipValues:     false,
 			want: []string{
-				"var First int = 1",
-				`var second string = "two"`,
-				"var Third float64 = 3.14",
-				"var fourth bool = true",
+				"const First = 1",
+				`const second = "two"`,
+				"const Third = 3.14",
+				"const fourth = true",
 			},
 		},
 		{
@@ -202,9 +222,43 @@ func TestProcessFile(t *testing.T) {
 			includePrivate: true,
 			skipValues:     true,
 			want: []string{
-				"func HandleRequest(ctx context.Context, req *Request) (*Response, error)",
-				"func GenericFunc(items []T) T",
-				"func Process(key K) (V, bool)",
+				"func (*Server) HandleRequest(ctx context.Context, req *Request) (*Response, error)",
+				"func GenericFunc[T any](items []T) T",
+				"func (*Thing[K, V]) Process(key K) (V, bool)",
+			},
+		},
+		{
+			name: "grouped, variadic and func-typed parameters",
+			code: `package test
+				func Add(a, b int) int { return a + b }
+				func Printf(format string, args ...any) (n int, err error) { return }
+				func Walk(root string, fn func(path string) error) error { return nil }
+				func Pair() (x, y float64) { return }
+				type Sizer interface{ Size(w, h int) (area int) }`,
+			includePrivate: true,
+			skipValues:     true,
+			want: []string{
+				"func Add(a, b int) int",
+				"func Printf(format string, args ...any) (n int, err error)",
+				"func Walk(root string, fn func(path string) error) error",
+				"func Pair() (x, y float64)",
+				"type Sizer interface { Size(w, h int) (area int) }",
+			},
+		},
+		{
+			name: "type parameter constraints",
+			code: `package test
+				type Number interface{ ~int | ~int64 | float64 }
+				type Pair[K comparable, V any] struct{ Key K; Value V }
+				func Sum[S ~[]E, E Number](s S) E { var e E; return e }
+				func Keys[A, B any](m map[A]B) []A { return nil }`,
+			includePrivate: true,
+			skipValues:     true,
+			want: []string{
+				"type Number interface { ~int | ~int64 | float64 }",
+				"type Pair[K comparable, V any] struct { Key K; Value V }",
+				"func Sum[S ~[]E, E Number](s S) E",
+				"func Keys[A, B any](m map[A]B) []A",
 			},
 		},
 		{
@@ -223,26 +277,43 @@ func TestProcessFile(t *testing.T) {
 				"var c []byte",
 			},
 		},
-		/*
-			{
-				name: "iota constants with type inference",
-				code: `package test
-					type Day int
-					const (
-						Sunday Day = iota
-						Monday
-						Tuesday
-					)`,
-				includePrivate: true,
-				skipValues:     false,
-				want: []string{
-					"type Day",
-					"var Sunday Day = iota",
-					"var Monday Day",
-					"var Tuesday Day",
-				},
+		{
+			name: "iota constants with type inference",
+			code: `package test
+				type Day int
+				const (
+					Sunday Day = iota
+					Monday
+					Tuesday
+				)`,
+			includePrivate: true,
+			skipValues:     false,
+			want: []string{
+				"type Day",
+				"const Sunday Day = 0",
+				"const Monday Day = 1",
+				"const Tuesday Day = 2",
 			},
-		*/
+		},
+		{
+			name: "iota expressions and implicit repetition",
+			code: `package test
+				const (
+					_ = iota
+					KB = 1 << (10 * iota)
+					MB
+					Answer = 42
+					Same
+				)`,
+			includePrivate: false,
+			skipValues:     false,
+			want: []string{
+				"const KB = 1024",
+				"const MB = 1048576",
+				"const Answer = 42",
+				"const Same = 42",
+			},
+		},
 		{
 			name: "complex interface type",
 			code: `package test
@@ -352,9 +423,9 @@ func TestProcessFile(t *testing.T) {
 			includePrivate: true,
 			skipValues:     true,
 			want: []string{
-				"type Stack struct { items []T }",
-				"func Process(m map[K]V)",
-				"type Container interface { Get() T; Set(value T) }",
+				"type Stack[T any] struct { items []T }",
+				"func Process[K comparable, V any](m map[K]V)",
+				"type Container[T any] interface { Get() T; Set(value T) }",
 			},
 		},
 		{
@@ -412,9 +483,9 @@ func TestProcessFile(t *testing.T) {
 			skipValues:     true,
 			want: []string{
 				"type Service struct { }",
-				"func Start(ctx context.Context) error",
-				"func Stop()",
-				"func Config() *Config",
+				"func (*Service) Start(ctx context.Context) error",
+				"func (Service) Stop()",
+				"func (*Service) Config() *Config",
 			},
 		},
 		/*
@@ -484,6 +555,44 @@ func TestProcessFile(t *testing.T) {
 	}
 }
 
+func TestNoParamNames(t *testing.T) {
+	includePrivate = false
+	skipValues = true
+	noParamNames = true
+	t.Cleanup(func() { noParamNames, skipValues = false, false })
+
+	filename := createTestFile(t, `package test
+		func Add(a, b int) int { return a + b }
+		func Printf(format string, args ...any) (n int, err error) { return }
+		func Len() (n int) { return }
+		func (s *Server) Handle(ctx context.Context, req *Request) (*Response, error) { return nil, nil }
+		type Sizer interface{ Size(w, h int) (area int) }
+		func Walk(root string, fn func(path string, err error) error) error { return nil }
+		type H func(w int, r string)
+		var Fn func(a, b int) (c int)`)
+
+	output := captureOutput(func() {
+		if err := processFile(filename, token.NewFileSet()); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	want := []string{
+		"test.go: func Add(int, int) int",
+		"test.go: func Printf(string, ...any) (int, error)",
+		"test.go: func Len() int",
+		"test.go: func (*Server) Handle(context.Context, *Request) (*Response, error)",
+		"test.go: type Sizer interface { Size(int, int) int }",
+		"test.go: func Walk(string, func(string, error) error) error",
+		"test.go: type H func(int, string)",
+		"test.go: var Fn func(int, int) int",
+	}
+	got := strings.Split(strings.TrimSpace(output), "\n")
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
 func TestProcessPath(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -540,7 +649,7 @@ func TestProcessPath(t *testing.T) {
 			want: []string{
 				`vars.go: var private string = "hidden"`,
 				`vars.go: var Public string = "visible"`,
-				`vars.go: var Long string = "this is a very long string...`,
+				`vars.go: const Long = "this is a very long string...`,
 			},
 		},
 		{
@@ -603,7 +712,7 @@ func TestProcessPath(t *testing.T) {
 			want: []string{
 				`mixed.go: var Version string = "1.0.0"`,
 				"mixed.go: func NewLogger() *logger",
-				"mixed.go: var DEBUG bool = true",
+				"mixed.go: const DEBUG = true",
 			},
 		},
 	}
@@ -620,18 +729,10 @@ func TestProcessPath(t *testing.T) {
 			workDir = tmpDir
 
 			// Create test files
+			writeTestFiles(t, tmpDir, tt.files)
 			createdFiles := make([]string, 0, len(tt.files))
-			for path, content := range tt.files {
-				fullPath := filepath.Join(tmpDir, path)
-				dir := filepath.Dir(fullPath)
-				if err := os.MkdirAll(dir, 0755); err != nil {
-					t.Fatal(err)
-				}
-				err := os.WriteFile(fullPath, []byte(content), 0644)
-				if err != nil {
-					t.Fatal(err)
-				}
-				createdFiles = append(createdFiles, fullPath)
+			for path := range tt.files {
+				createdFiles = append(createdFiles, filepath.Join(tmpDir, path))
 			}
 
 			// Run the test
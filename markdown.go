@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Render an API reference in Markdown: one section per package, and one
+// sub-section per type with its constructors and methods
+func writeMarkdown(w io.Writer, pkgs []*packageGroup) {
+	for i, pkg := range pkgs {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		if pkg.Kind != "" {
+			fmt.Fprintf(w, "# `%s` (%s)\n", pkg.Path, pkg.Kind)
+		} else {
+			fmt.Fprintf(w, "# `%s`\n", pkg.Path)
+		}
+
+		if len(pkg.Consts) > 0 {
+			fmt.Fprintf(w, "\n## Constants\n")
+			writeMarkdownValues(w, pkg.Consts)
+		}
+		if len(pkg.Vars) > 0 {
+			fmt.Fprintf(w, "\n## Variables\n")
+			writeMarkdownValues(w, pkg.Vars)
+		}
+		if len(pkg.Funcs) > 0 {
+			fmt.Fprintf(w, "\n## Functions\n")
+			for _, d := range pkg.Funcs {
+				writeMarkdownDecl(w, "###", "func "+d.Name, d)
+			}
+		}
+		if len(pkg.Types) > 0 {
+			fmt.Fprintf(w, "\n## Types\n")
+			for _, tg := range pkg.Types {
+				if tg.Decl != nil {
+					writeMarkdownDecl(w, "###", "type "+tg.Name, tg.Decl)
+					if len(tg.Decl.Members) > 0 {
+						fmt.Fprintf(w, "\n_%s_\n", enumNote(tg.Decl))
+					}
+				} else {
+					fmt.Fprintf(w, "\n### type %s\n", tg.Name)
+				}
+				if len(tg.Consts) > 0 {
+					writeMarkdownValues(w, tg.Consts)
+				}
+				for _, d := range tg.Constructors {
+					writeMarkdownDecl(w, "####", "func "+d.Name, d)
+				}
+				for _, d := range tg.Methods {
+					writeMarkdownDecl(w, "####", fmt.Sprintf("func (%s) %s", d.Receiver, d.Name), d)
+				}
+			}
+		}
+	}
+}
+
+// Write a heading, the signature of a declaration as a Go code block, and
+// its doc comment as prose
+func writeMarkdownDecl(w io.Writer, level, title string, d *Decl) {
+	fmt.Fprintf(w, "\n%s %s\n\n", level, title)
+	fmt.Fprintf(w, "```go\n%s\n```\n", declSource(d))
+	if d.Doc != "" {
+		fmt.Fprintf(w, "\n%s\n", d.Doc)
+	}
+}
+
+// Write consecutive values sharing the same doc comment as a single code block
+func writeMarkdownValues(w io.Writer, decls []*Decl) {
+	for i := 0; i < len(decls); {
+		j := i + 1
+		for j < len(decls) && decls[j].Doc == decls[i].Doc && decls[j].File == decls[i].File {
+			j++
+		}
+
+		lines := make([]string, 0, j-i)
+		for _, d := range decls[i:j] {
+			lines = append(lines, d.Signature)
+		}
+		fmt.Fprintf(w, "\n```go\n%s\n```\n", strings.Join(lines, "\n"))
+		if doc := decls[i].Doc; doc != "" {
+			fmt.Fprintf(w, "\n%s\n", doc)
+		}
+		i = j
+	}
+}
+
+// Write the Markdown reference of the collected declarations to stdout
+func flushMarkdown(decls []*Decl) error {
+	writeMarkdown(os.Stdout, groupDecls(decls))
+	return nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func TestMarkdownOutput(t *testing.T) {
+	includePrivate = false
+	skipValues = false
+	maxValueLength = 30
+	outputFormat = formatMarkdown
+	t.Cleanup(func() { outputFormat = formatText })
+
+	filename := createTestFile(t, `package test
+
+// Version of the client.
+const Version = "1.0"
+
+// Client talks to the server.
+type Client struct{}
+
+// Do sends a request.
+func (c *Client) Do() error { return nil }
+
+func (c *Client) Close() error { return nil }
+
+// NewClient returns a new client.
+func NewClient() (*Client, error) { return nil, nil }
+
+// Helper does things.
+func Helper() {}
+`)
+
+	output := captureOutput(func() {
+		if err := processFile(filename, token.NewFileSet()); err != nil {
+			t.Fatal(err)
+		}
+		if err := flushDecls(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	want := "# `.`\n" +
+		"\n## Constants\n" +
+		"\n```go\nconst Version = \"1.0\"\n```\n" +
+		"\nVersion of the client.\n" +
+		"\n## Functions\n" +
+		"\n### func Helper\n\n```go\nfunc Helper()\n```\n" +
+		"\nHelper does things.\n" +
+		"\n## Types\n" +
+		"\n### type Client\n\n```go\ntype Client struct { }\n```\n" +
+		"\nClient talks to the server.\n" +
+		"\n#### func NewClient\n\n```go\nfunc NewClient() (*Client, error)\n```\n" +
+		"\nNewClient returns a new client.\n" +
+		"\n#### func (*Client) Close\n\n```go\nfunc (*Client) Close() error\n```\n" +
+		"\n#### func (*Client) Do\n\n```go\nfunc (*Client) Do() error\n```\n" +
+		"\nDo sends a request.\n"
+	if output != want {
+		t.Errorf("got:\n%s\nwant:\n%s", output, want)
+		gotLines, wantLines := strings.Split(output, "\n"), strings.Split(want, "\n")
+		for i := 0; i < len(gotLines) && i < len(wantLines); i++ {
+			if gotLines[i] != wantLines[i] {
+				t.Errorf("first difference at line %d:\ngot:  %q\nwant: %q", i+1, gotLines[i], wantLines[i])
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Output formats
+const (
+	formatText     = "text"
+	formatJSON     = "json"
+	formatJSONL    = "jsonl"
+	formatMarkdown = "markdown"
+)
+
+var (
+	outputFormat  = formatText
+	showPositions bool
+	groupView     bool
+
+	// Declarations buffered until the end of the run for formats that need
+	// the complete set (e.g. a single JSON array, or grouping by package)
+	collectedDecls []*Decl
+)
+
+// Render declarations in the selected output format
+func emitDecls(decls []*Decl) error {
+	if checkDoc {
+		decls = undocumented(decls)
+		undocumentedCount += len(decls)
+	}
+
+	if testsMode {
+		decls = splitTestDecls(decls)
+	}
+	return writeDecls(decls)
+}
+
+// Write declarations, or buffer them for formats that need the complete set
+func writeDecls(decls []*Decl) error {
+	if groupView || stubMode || implementsMode {
+		collectedDecls = append(collectedDecls, decls...)
+		return nil
+	}
+
+	switch outputFormat {
+	case formatJSON, formatMarkdown:
+		collectedDecls = append(collectedDecls, decls...)
+	case formatJSONL:
+		enc := newJSONEncoder()
+		for _, d := range decls {
+			if err := enc.Encode(d); err != nil {
+				return err
+			}
+		}
+	default:
+		for _, d := range decls {
+			fmt.Println(formatDeclLine(d))
+		}
+	}
+	return nil
+}
+
+// Write the output of formats that buffer declarations
+func flushDecls() error {
+	if testsMode {
+		if err := writeDecls(untestedDecls()); err != nil {
+			return err
+		}
+	}
+
+	decls := collectedDecls
+	collectedDecls = nil
+
+	if implementsMode {
+		annotateImplements(decls)
+	}
+
+	switch outputFormat {
+	case formatJSON:
+		if groupView {
+			decls = flattenGroups(groupDecls(decls))
+		}
+		if decls == nil {
+			decls = []*Decl{}
+		}
+		enc := newJSONEncoder()
+		enc.SetIndent("", "  ")
+		return enc.Encode(decls)
+	case formatJSONL:
+		if groupView {
+			decls = flattenGroups(groupDecls(decls))
+		}
+		enc := newJSONEncoder()
+		for _, d := range decls {
+			if err := enc.Encode(d); err != nil {
+				return err
+			}
+		}
+	case formatMarkdown:
+		return flushMarkdown(decls)
+	default:
+		if !groupView {
+			for _, d := range decls {
+				fmt.Println(formatDeclLine(d))
+			}
+			return nil
+		}
+
+		// Grouped text view, with a blank line between packages
+		for i, pkg := range groupDecls(decls) {
+			if i > 0 {
+				fmt.Println()
+			}
+			for _, d := range pkg.flatten() {
+				fmt.Println(formatDeclLine(d))
+			}
+		}
+	}
+	return nil
+}
+
+// Format a declaration as a line of text output, preceded by its doc
+// comment lines in -doc=full mode
+func formatDeclLine(d *Decl) string {
+	prefix := d.File
+	if showPositions {
+		prefix = fmt.Sprintf("%s:%d:%d", d.File, d.Line, d.Column)
+	} else if loadPackages && d.Package != "" {
+		prefix = d.Package
+	}
+
+	// Collect trailing notes
+	var notes []string
+	if docMode == docFirst && d.Doc != "" {
+		notes = append(notes, synopsis(d.Doc))
+	}
+	if len(d.Members) > 0 {
+		notes = append(notes, enumNote(d))
+	}
+	if len(d.Implements) > 0 {
+		notes = append(notes, "implements: "+strings.Join(d.Implements, ", "))
+	}
+	if len(d.PointerImplements) > 0 {
+		notes = append(notes, "pointer implements: "+strings.Join(d.PointerImplements, ", "))
+	}
+	if len(d.ImplementedBy) > 0 {
+		notes = append(notes, "implemented by: "+strings.Join(d.ImplementedBy, ", "))
+	}
+	if d.Underlying != "" {
+		notes = append(notes, "underlying: "+d.Underlying)
+	}
+	switch {
+	case d.Test == testKindExample && d.Example != "":
+		notes = append(notes, "example: "+d.Example)
+	case d.Test != "":
+		notes = append(notes, d.Test)
+	case d.Untested:
+		notes = append(notes, "untested")
+	}
+	if d.Constraint != "" {
+		notes = append(notes, "build: "+d.Constraint)
+	}
+	if d.PackageKind != "" {
+		notes = append(notes, gnoKindNote(d.PackageKind))
+	}
+	if d.Crossing {
+		notes = append(notes, "crossing")
+	}
+	if d.Persistent {
+		notes = append(notes, "realm state")
+	}
+
+	if prettyMode {
+		return formatDeclBlock(prefix, notes, d)
+	}
+
+	line := fmt.Sprintf("%s: %s", prefix, d.Signature)
+	if len(notes) > 0 {
+		line += " // " + strings.Join(notes, "; ")
+	}
+
+	if docMode == docFull && d.Doc != "" {
+		var buf strings.Builder
+		for _, comment := range docCommentLines(d.Doc) {
+			fmt.Fprintf(&buf, "%s: %s\n", prefix, comment)
+		}
+		return buf.String() + line
+	}
+	return line
+}
+
+func newJSONEncoder() *json.Encoder {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetEscapeHTML(false)
+	return enc
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"go/token"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestJSONOutput(t *testing.T) {
+	includePrivate = false
+	skipValues = false
+	maxValueLength = 30
+	t.Cleanup(func() { outputFormat = formatText })
+
+	filename := createTestFile(t, `package test
+
+// Service runs things.
+type Service struct{}
+
+// Start starts the service.
+func (s *Service) Start() error { return nil }
+
+const Version = "1.0"
+`)
+
+	for _, format := range []string{formatJSON, formatJSONL} {
+		t.Run(format, func(t *testing.T) {
+			outputFormat = format
+			output := captureOutput(func() {
+				if err := processFile(filename, token.NewFileSet()); err != nil {
+					t.Fatal(err)
+				}
+				if err := flushDecls(); err != nil {
+					t.Fatal(err)
+				}
+			})
+
+			var got []Decl
+			if format == formatJSON {
+				if err := json.Unmarshal([]byte(output), &got); err != nil {
+					t.Fatalf("invalid JSON: %v\n%s", err, output)
+				}
+			} else {
+				for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+					var d Decl
+					if err := json.Unmarshal([]byte(line), &d); err != nil {
+						t.Fatalf("invalid JSON line: %v\n%s", err, line)
+					}
+					got = append(got, d)
+				}
+			}
+
+			want := []Decl{
+				{File: "test.go", Line: 4, Column: 6, Kind: KindType, Name: "Service", Signature: "type Service struct { }", Exported: true, Doc: "Service runs things."},
+				{File: "test.go", Line: 7, Column: 19, Kind: KindMethod, Name: "Start", Receiver: "*Service", Signature: "func (*Service) Start() error", Exported: true, Doc: "Start starts the service."},
+				{File: "test.go", Line: 9, Column: 7, Kind: KindConst, Name: "Version", Signature: `const Version = "1.0"`, Exported: true, Value: `"1.0"`},
+			}
+			if len(got) != len(want) {
+				t.Fatalf("got %d declarations, want %d\n%s", len(got), len(want), output)
+			}
+			for i := range want {
+				if !reflect.DeepEqual(got[i], want[i]) {
+					t.Errorf("declaration %d:\ngot:  %+v\nwant: %+v", i, got[i], want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPositions(t *testing.T) {
+	includePrivate = false
+	skipValues = true
+	maxValueLength = 30
+	showPositions = true
+	t.Cleanup(func() { showPositions, skipValues = false, false })
+
+	filename := createTestFile(t, `package test
+
+type Service struct{}
+
+func (s *Service) Start() error { return nil }
+
+var (
+	A, B int
+)
+`)
+
+	output := captureOutput(func() {
+		if err := processFile(filename, token.NewFileSet()); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	want := []string{
+		"test.go:3:6: type Service struct { }",
+		"test.go:5:19: func (*Service) Start() error",
+		"test.go:8:2: var A int",
+		"test.go:8:5: var B int",
+	}
+	got := strings.Split(strings.TrimSpace(output), "\n")
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
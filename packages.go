@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"os"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// Resolve Go package patterns with go/packages and process the files the
+// compiler would see, grouped by import path
+func processPackages(patterns []string, fset *token.FileSet) error {
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles,
+		Dir:  workDir,
+		// Test variants of the packages include their _test.go files
+		Tests: testsMode,
+	}
+	if flags, env := packagesBuildConfig(); len(flags) > 0 || len(env) > 0 {
+		cfg.BuildFlags = flags
+		cfg.Env = append(os.Environ(), env...)
+	}
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return fmt.Errorf("error loading packages: %v", err)
+	}
+
+	excludes := splitExcludes()
+
+	// Sort packages by import path
+	sort.Slice(pkgs, func(i, j int) bool {
+		return pkgs[i].PkgPath < pkgs[j].PkgPath
+	})
+
+	// Merge the files of the variants of a package (e.g. "p" and its test
+	// variant "p [p.test]"), skipping generated test mains
+	var pkgPaths []string
+	filesByPkg := make(map[string][]string)
+	seen := make(map[string]bool)
+	for _, pkg := range pkgs {
+		if len(pkg.Errors) > 0 {
+			return fmt.Errorf("error loading package %s: %v", pkg.PkgPath, pkg.Errors[0])
+		}
+		if strings.HasSuffix(pkg.ID, ".test") {
+			continue
+		}
+		if _, ok := filesByPkg[pkg.PkgPath]; !ok {
+			pkgPaths = append(pkgPaths, pkg.PkgPath)
+			filesByPkg[pkg.PkgPath] = []string{}
+		}
+		for _, file := range pkg.GoFiles {
+			if !seen[file] && !isExcluded(file, excludes) {
+				seen[file] = true
+				filesByPkg[pkg.PkgPath] = append(filesByPkg[pkg.PkgPath], file)
+			}
+		}
+	}
+
+	for _, pkgPath := range pkgPaths {
+		files := filesByPkg[pkgPath]
+		sort.Strings(files)
+
+		err := extractFiles(files, fset, func(decls []*Decl) error {
+			for _, d := range decls {
+				d.Package = pkgPath
+			}
+			return emitDecls(decls)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func TestProcessPackages(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/m\n\ngo 1.21\n",
+		"a/a.go": `package a
+			func A() {}`,
+		"a/a_test.go": `package a
+			func TestA() {}`,
+		"a/ignored.go": `//go:build ignore
+
+			package a
+			func Ignored() {}`,
+		"b/b.go": `package b
+			type B struct{}`,
+		"b/testdata/c.go": `package c
+			func C() {}`,
+	}
+	writeTestFiles(t, tmpDir, files)
+
+	includePrivate = false
+	skipValues = true
+	workDir = tmpDir
+	loadPackages = true
+	t.Cleanup(func() { loadPackages, skipValues, workDir = false, false, "" })
+
+	var gotErr error
+	output := captureOutput(func() {
+		gotErr = processPackages([]string{"./..."}, token.NewFileSet())
+	})
+	if gotErr != nil {
+		t.Fatal(gotErr)
+	}
+
+	got := strings.Split(strings.TrimSpace(output), "\n")
+	want := []string{
+		"example.com/m/a: func A()",
+		"example.com/m/b: type B struct { }",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
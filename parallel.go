@@ -0,0 +1,74 @@
+package main
+
+import (
+	"go/token"
+	"runtime"
+	"sync"
+)
+
+// Maximum number of files parsed concurrently (0 means GOMAXPROCS)
+var parallelism int
+
+// Extract the declarations of files concurrently, handing them to emit in
+// the order of files so that the output stays deterministic
+func extractFiles(files []string, fset *token.FileSet, emit func(decls []*Decl) error) error {
+	type result struct {
+		decls []*Decl
+		err   error
+	}
+
+	workers := parallelism
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(files) {
+		workers = len(files)
+	}
+
+	// One buffered channel per file, so that workers never block on results
+	results := make([]chan result, len(files))
+	for i := range results {
+		results[i] = make(chan result, 1)
+	}
+
+	// Feed file indexes to the workers until done, or until we stop early.
+	// Workers read shared state, so they are all waited for before returning.
+	jobs := make(chan int)
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	defer close(done)
+	go func() {
+		defer close(jobs)
+		for i := range files {
+			select {
+			case jobs <- i:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				decls, err := extractDecls(files[i], nil, fset)
+				results[i] <- result{decls: decls, err: err}
+			}
+		}()
+	}
+
+	// Emit results in order
+	for i := range files {
+		r := <-results[i]
+		if err := keepGoingOn(r.err); err != nil {
+			return err
+		}
+		if err := emit(r.decls); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParallelOutputOrder(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := make(map[string]string)
+	for i := 0; i < 40; i++ {
+		path := filepath.Join(fmt.Sprintf("pkg%d", i%4), fmt.Sprintf("file%02d.go", i))
+		files[path] = fmt.Sprintf("package pkg%d\nfunc F%d() {}\ntype T%d struct{}\n", i%4, i, i)
+	}
+	writeTestFiles(t, tmpDir, files)
+
+	includePrivate = false
+	skipValues = true
+	fileExtensions = ".go"
+	excludeSuffixes = "_test.go"
+	workDir = tmpDir
+	t.Cleanup(func() { parallelism, skipValues, workDir = 0, false, "" })
+
+	run := func(j int) string {
+		parallelism = j
+		return captureOutput(func() {
+			if err := processPath(tmpDir, token.NewFileSet()); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+
+	sequential := run(1)
+	if lines := strings.Split(strings.TrimSpace(sequential), "\n"); len(lines) != 80 {
+		t.Fatalf("got %d lines, want 80", len(lines))
+	}
+	for _, j := range []int{2, 8, 64} {
+		if got := run(j); got != sequential {
+			t.Errorf("-j %d output differs from sequential output:\n%s", j, got)
+		}
+	}
+}
+
+func TestParallelStopsOnError(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"a.go": "package test\nfunc A() {}\n",
+		"b.go": "package test\nthis is not valid go code\n",
+		"c.go": "package test\nfunc C() {}\n",
+	}
+	writeTestFiles(t, tmpDir, files)
+
+	includePrivate = false
+	fileExtensions = ".go"
+	excludeSuffixes = "_test.go"
+	workDir = tmpDir
+	parallelism = 4
+	t.Cleanup(func() { parallelism, workDir = 0, "" })
+
+	var gotErr error
+	output := captureOutput(func() {
+		gotErr = processPath(tmpDir, token.NewFileSet())
+	})
+	if gotErr == nil {
+		t.Fatal("expected a parse error")
+	}
+	if got := strings.TrimSpace(output); got != "a.go: func A()" {
+		t.Errorf("unexpected output before the error: %q", got)
+	}
+}
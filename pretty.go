@@ -0,0 +1,110 @@
+package main
+
+import (
+	"go/ast"
+	"go/format"
+	"go/printer"
+	"go/token"
+	"strings"
+)
+
+// Print declarations as gofmt-formatted source, with -pretty
+var prettyMode bool
+
+// Render a type declaration as gofmt-formatted source, keeping the comments
+// of its fields and methods but not its doc comment
+func prettyTypeSpec(s *ast.TypeSpec, f *ast.File, fset *token.FileSet) string {
+	spec := *s
+	spec.Doc, spec.Comment = nil, nil
+	comments := f.Comments
+
+	// Drop the fields hidden by -exported-fields, with their comments
+	if st, ok := s.Type.(*ast.StructType); ok && st.Fields != nil {
+		list := visibleFields(s.Name, st.Fields.List)
+		if len(list) != len(st.Fields.List) {
+			hidden := make(map[*ast.CommentGroup]bool)
+			for _, field := range st.Fields.List {
+				hidden[field.Doc], hidden[field.Comment] = true, true
+			}
+			for _, field := range list {
+				delete(hidden, field.Doc)
+				delete(hidden, field.Comment)
+			}
+			comments = nil
+			for _, c := range f.Comments {
+				if !hidden[c] {
+					comments = append(comments, c)
+				}
+			}
+
+			structType := *st
+			fields := *st.Fields
+			fields.List = list
+			structType.Fields = &fields
+			spec.Type = &structType
+		}
+	}
+
+	decl := &ast.GenDecl{TokPos: s.Pos(), Tok: token.TYPE, Specs: []ast.Spec{&spec}}
+	return prettySource(decl, comments, fset)
+}
+
+// Render a constant or variable spec as a gofmt-formatted declaration of its
+// own, keeping the comments inside its values but not its doc comment. All
+// the names of the spec share its source, e.g. "var X, Y = f()".
+func prettyValueSpec(s *ast.ValueSpec, tok token.Token, comments []*ast.CommentGroup, fset *token.FileSet) string {
+	spec := *s
+	spec.Doc, spec.Comment = nil, nil
+	decl := &ast.GenDecl{TokPos: s.Pos(), Tok: tok, Specs: []ast.Spec{&spec}}
+	return prettySource(decl, comments, fset)
+}
+
+// Render a function declaration as gofmt-formatted source, without its body
+// and doc comment
+func prettyFuncDecl(d *ast.FuncDecl, f *ast.File, fset *token.FileSet) string {
+	fn := *d
+	fn.Doc, fn.Body = nil, nil
+	return prettySource(&fn, f.Comments, fset)
+}
+
+// Helper function to print a node like gofmt, with the comments of the file
+// that are inside the node. Returns an empty string if it cannot be printed.
+func prettySource(node ast.Node, comments []*ast.CommentGroup, fset *token.FileSet) string {
+	var buf strings.Builder
+	if err := format.Node(&buf, fset, &printer.CommentedNode{Node: node, Comments: comments}); err != nil {
+		return ""
+	}
+	return buf.String()
+}
+
+// Helper function to get the source of a declaration: its formatted source
+// in -pretty mode, otherwise its signature
+func declSource(d *Decl) string {
+	if d.Source != "" {
+		return d.Source
+	}
+	return d.Signature
+}
+
+// Format a declaration as a block of source in -pretty mode: a comment with
+// its location and notes, its doc comment in -doc=full mode, then its source
+// and a blank line
+func formatDeclBlock(prefix string, notes []string, d *Decl) string {
+	var buf strings.Builder
+	buf.WriteString("// ")
+	buf.WriteString(prefix)
+	if len(notes) > 0 {
+		buf.WriteString(": ")
+		buf.WriteString(strings.Join(notes, "; "))
+	}
+	buf.WriteString("\n")
+	if docMode == docFull && d.Doc != "" {
+		for _, comment := range docCommentLines(d.Doc) {
+			buf.WriteString(comment)
+			buf.WriteString("\n")
+		}
+	}
+	buf.WriteString(declSource(d))
+	buf.WriteString("\n")
+	return buf.String()
+}
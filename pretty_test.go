@@ -0,0 +1,124 @@
+package main
+
+import (
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func TestPrettyOutput(t *testing.T) {
+	includePrivate = false
+	skipValues = false
+	maxValueLength = 30
+	prettyMode = true
+	t.Cleanup(func() { prettyMode = false })
+
+	filename := createTestFile(t, `package test
+
+// Config is the configuration.
+type Config struct {
+	// Name of the service.
+	Name string `+"`json:\"name\"`"+` // display name
+	Port int
+	ReadTimeout time.Duration
+}
+
+type (
+	// Store stores values.
+	Store interface {
+		Get(key string) (string, error)
+		Set(key, value string) error // overwrites
+	}
+)
+
+// New creates a config.
+func New(
+	name string, // service name
+	port int,
+) (*Config, error) {
+	return &Config{Name: name, Port: port}, nil
+}
+
+func (c *Config) Addr() string { return "" }
+
+const Version = "1.0"
+
+const (
+	KB = 1 << (10 * (iota + 1)) // kilobyte
+	MB
+)
+
+const (
+	A = 5
+	B = iota
+	C
+)
+
+var Width, Height = size()
+
+var Defaults = map[string]int{
+	"port": 80, // http
+}
+`)
+
+	output := captureOutput(func() {
+		if err := processFile(filename, token.NewFileSet()); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	want := "// test.go\n" +
+		"type Config struct {\n" +
+		"\t// Name of the service.\n" +
+		"\tName        string `json:\"name\"` // display name\n" +
+		"\tPort        int\n" +
+		"\tReadTimeout time.Duration\n" +
+		"}\n" +
+		"\n" +
+		"// test.go\n" +
+		"type Store interface {\n" +
+		"\tGet(key string) (string, error)\n" +
+		"\tSet(key, value string) error // overwrites\n" +
+		"}\n" +
+		"\n" +
+		"// test.go\n" +
+		"func New(\n" +
+		"\tname string, // service name\n" +
+		"\tport int,\n" +
+		") (*Config, error)\n" +
+		"\n" +
+		"// test.go\n" +
+		"func (c *Config) Addr() string\n" +
+		"\n" +
+		"// test.go\n" +
+		"const Version = \"1.0\"\n" +
+		"\n" +
+		"// test.go\n" +
+		"const KB = 1024\n" +
+		"\n" +
+		"// test.go\n" +
+		"const MB = 1048576\n" +
+		"\n" +
+		"// test.go\n" +
+		"const A = 5\n" +
+		"\n" +
+		"// test.go\n" +
+		"const B = 1\n" +
+		"\n" +
+		"// test.go\n" +
+		"const C = 2\n" +
+		"\n" +
+		"// test.go\n" +
+		"var Width, Height = size()\n" +
+		"\n" +
+		"// test.go\n" +
+		"var Width, Height = size()\n" +
+		"\n" +
+		"// test.go\n" +
+		"var Defaults = map[string]int{\n" +
+		"\t\"port\": 80, // http\n" +
+		"}\n"
+	if got := strings.TrimSpace(output) + "\n"; got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
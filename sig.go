@@ -0,0 +1,295 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/types"
+	"path"
+	"strings"
+)
+
+// sigPattern is a compiled -sig query, matched against function declarations.
+//
+// A query looks like a function declaration without parameter names:
+//
+//	func(context.Context, ...)       first parameter is a context.Context
+//	func(...) (*_, error)            returns a pointer and an error
+//	func (*_) _(...) error           methods with a pointer receiver returning an error
+//	func New*(...string)             functions named New* with a variadic string parameter
+//
+// "_" matches any single type, "..." alone matches any number of parameters
+// or results, and "...T" matches a variadic T parameter. Omitted results match
+// any results, while "()" matches functions without results.
+type sigPattern struct {
+	recv    ast.Expr   // nil when the query has no receiver
+	name    string     // glob on the function name, empty for any name
+	params  []ast.Expr // nil elements stand for "..."
+	results []ast.Expr // nil elements stand for "..."
+	anyRes  bool       // results were omitted
+}
+
+// Parse a -sig query
+func parseSigPattern(query string) (*sigPattern, error) {
+	rest := strings.TrimSpace(query)
+	if !strings.HasPrefix(rest, "func") {
+		return nil, fmt.Errorf("signature query must start with func: %s", query)
+	}
+	rest = strings.TrimSpace(strings.TrimPrefix(rest, "func"))
+	p := &sigPattern{}
+
+	// Optional receiver: only when a name follows the first parenthesized group
+	if strings.HasPrefix(rest, "(") {
+		group, after, err := cutGroup(rest)
+		if err != nil {
+			return nil, err
+		}
+		if name, tail := cutName(strings.TrimSpace(after)); name != "" && strings.HasPrefix(tail, "(") {
+			recv, err := parseTypePattern(group)
+			if err != nil {
+				return nil, err
+			}
+			p.recv, p.name, rest = recv, name, tail
+		}
+	} else {
+		p.name, rest = cutName(rest)
+	}
+	if p.name == "_" {
+		p.name = ""
+	}
+
+	// Parameters
+	group, rest, err := cutGroup(rest)
+	if err != nil {
+		return nil, err
+	}
+	if p.params, err = parseTypePatterns(group); err != nil {
+		return nil, err
+	}
+
+	// Results
+	rest = strings.TrimSpace(rest)
+	switch {
+	case rest == "":
+		p.anyRes = true
+	case strings.HasPrefix(rest, "("):
+		group, after, err := cutGroup(rest)
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(after) != "" {
+			return nil, fmt.Errorf("unexpected %q in signature query", after)
+		}
+		if p.results, err = parseTypePatterns(group); err != nil {
+			return nil, err
+		}
+	default:
+		if p.results, err = parseTypePatterns(rest); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// Split a leading parenthesized group from the rest of the text
+func cutGroup(s string) (group, rest string, err error) {
+	if !strings.HasPrefix(s, "(") {
+		return "", "", fmt.Errorf("expected ( in signature query: %s", s)
+	}
+	depth := 0
+	for i, r := range s {
+		switch r {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+			if depth == 0 {
+				return s[1:i], s[i+1:], nil
+			}
+		}
+	}
+	return "", "", fmt.Errorf("unbalanced parentheses in signature query: %s", s)
+}
+
+// Split a leading name (possibly a glob) from the rest of the text
+func cutName(s string) (name, rest string) {
+	i := strings.IndexAny(s, "( ")
+	if i < 0 {
+		return s, ""
+	}
+	return s[:i], strings.TrimSpace(s[i:])
+}
+
+// Parse a comma-separated list of type patterns
+func parseTypePatterns(list string) ([]ast.Expr, error) {
+	patterns := []ast.Expr{}
+	for _, elem := range splitTopLevel(list) {
+		elem = strings.TrimSpace(elem)
+		switch {
+		case elem == "":
+			continue
+		case elem == "...":
+			patterns = append(patterns, nil)
+		case strings.HasPrefix(elem, "..."):
+			elt, err := parseTypePattern(elem[3:])
+			if err != nil {
+				return nil, err
+			}
+			patterns = append(patterns, &ast.Ellipsis{Elt: elt})
+		default:
+			expr, err := parseTypePattern(elem)
+			if err != nil {
+				return nil, err
+			}
+			patterns = append(patterns, expr)
+		}
+	}
+	return patterns, nil
+}
+
+// Parse a single type pattern
+func parseTypePattern(s string) (ast.Expr, error) {
+	expr, err := parser.ParseExpr(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("invalid type %q in signature query: %v", s, err)
+	}
+	return expr, nil
+}
+
+// Split a list on commas that are not nested in brackets
+func splitTopLevel(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, r := range s {
+		switch r {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// Check whether a function declaration matches the query
+func (p *sigPattern) matches(decl *ast.FuncDecl) bool {
+	if p.name != "" {
+		if ok, _ := path.Match(p.name, decl.Name.Name); !ok {
+			return false
+		}
+	}
+	if p.recv != nil {
+		if decl.Recv == nil || len(decl.Recv.List) == 0 {
+			return false
+		}
+		if !matchTypePattern(p.recv, stripTypeArgs(decl.Recv.List[0].Type)) {
+			return false
+		}
+	}
+	if !matchTypePatterns(p.params, fieldTypes(decl.Type.Params)) {
+		return false
+	}
+	return p.anyRes || matchTypePatterns(p.results, fieldTypes(decl.Type.Results))
+}
+
+// List the type of each parameter or result, repeated for grouped names
+func fieldTypes(fl *ast.FieldList) []ast.Expr {
+	var list []ast.Expr
+	if fl == nil {
+		return list
+	}
+	for _, field := range fl.List {
+		n := len(field.Names)
+		if n == 0 {
+			n = 1
+		}
+		for i := 0; i < n; i++ {
+			list = append(list, field.Type)
+		}
+	}
+	return list
+}
+
+// Match a list of types against patterns, where nil patterns match any
+// number of types
+func matchTypePatterns(patterns, exprs []ast.Expr) bool {
+	if len(patterns) == 0 {
+		return len(exprs) == 0
+	}
+	if patterns[0] == nil {
+		for i := 0; i <= len(exprs); i++ {
+			if matchTypePatterns(patterns[1:], exprs[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	return len(exprs) > 0 && matchTypePattern(patterns[0], exprs[0]) && matchTypePatterns(patterns[1:], exprs[1:])
+}
+
+// Match a type expression against a pattern, where "_" matches any type
+func matchTypePattern(pattern, expr ast.Expr) bool {
+	expr = ast.Unparen(expr)
+	switch p := ast.Unparen(pattern).(type) {
+	case *ast.Ident:
+		if p.Name == "_" {
+			return true
+		}
+		e, ok := expr.(*ast.Ident)
+		return ok && e.Name == p.Name
+	case *ast.SelectorExpr:
+		e, ok := expr.(*ast.SelectorExpr)
+		return ok && p.Sel.Name == e.Sel.Name && matchTypePattern(p.X, e.X)
+	case *ast.StarExpr:
+		e, ok := expr.(*ast.StarExpr)
+		return ok && matchTypePattern(p.X, e.X)
+	case *ast.Ellipsis:
+		e, ok := expr.(*ast.Ellipsis)
+		return ok && matchTypePattern(p.Elt, e.Elt)
+	case *ast.ArrayType:
+		e, ok := expr.(*ast.ArrayType)
+		if !ok || (p.Len == nil) != (e.Len == nil) {
+			return false
+		}
+		if p.Len != nil && types.ExprString(p.Len) != "_" && types.ExprString(p.Len) != types.ExprString(e.Len) {
+			return false
+		}
+		return matchTypePattern(p.Elt, e.Elt)
+	case *ast.MapType:
+		e, ok := expr.(*ast.MapType)
+		return ok && matchTypePattern(p.Key, e.Key) && matchTypePattern(p.Value, e.Value)
+	case *ast.ChanType:
+		e, ok := expr.(*ast.ChanType)
+		return ok && p.Dir == e.Dir && matchTypePattern(p.Value, e.Value)
+	case *ast.FuncType:
+		e, ok := expr.(*ast.FuncType)
+		return ok && matchTypePatterns(fieldTypes(p.Params), fieldTypes(e.Params)) &&
+			matchTypePatterns(fieldTypes(p.Results), fieldTypes(e.Results))
+	case *ast.IndexExpr:
+		e, ok := expr.(*ast.IndexExpr)
+		return ok && matchTypePattern(p.X, e.X) && matchTypePattern(p.Index, e.Index)
+	case *ast.IndexListExpr:
+		e, ok := expr.(*ast.IndexListExpr)
+		return ok && matchTypePattern(p.X, e.X) && matchTypePatterns(p.Indices, e.Indices)
+	default:
+		return types.ExprString(pattern) == types.ExprString(expr)
+	}
+}
+
+// Helper function to drop the type arguments of a generic receiver type
+func stripTypeArgs(expr ast.Expr) ast.Expr {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return &ast.StarExpr{X: stripTypeArgs(e.X)}
+	case *ast.IndexExpr:
+		return e.X
+	case *ast.IndexListExpr:
+		return e.X
+	}
+	return expr
+}
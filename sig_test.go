@@ -0,0 +1,160 @@
+package main
+
+import (
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func TestSigQuery(t *testing.T) {
+	code := `package test
+		import (
+			"context"
+			"io"
+		)
+		type Client struct{}
+		type Cache[K comparable, V any] struct{}
+		func NewClient(ctx context.Context) (*Client, error) { return nil, nil }
+		func Dial(ctx context.Context, addr string, opts ...string) (*Client, error) { return nil, nil }
+		func Copy(dst io.Writer, src io.Reader) (int64, error) { return 0, nil }
+		func Join(elems []string, sep string) string { return "" }
+		func Watch(ctx context.Context) <-chan string { return nil }
+		func (c *Client) Close() error { return nil }
+		func (c Client) Ping() {}
+		func (c *Cache[K, V]) Get(key K) (V, bool) { var v V; return v, false }
+		var Default = &Client{}`
+
+	tests := []struct {
+		name    string
+		sig     string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "context first",
+			sig:  "func(context.Context, ...)",
+			want: []string{
+				"func NewClient(ctx context.Context) (*Client, error)",
+				"func Dial(ctx context.Context, addr string, opts ...string) (*Client, error)",
+				"func Watch(ctx context.Context) <-chan string",
+			},
+		},
+		{
+			name: "pointer and error results",
+			sig:  "func(...) (*_, error)",
+			want: []string{
+				"func NewClient(ctx context.Context) (*Client, error)",
+				"func Dial(ctx context.Context, addr string, opts ...string) (*Client, error)",
+			},
+		},
+		{
+			name: "variadic parameter",
+			sig:  "func(..., ...string)",
+			want: []string{
+				"func Dial(ctx context.Context, addr string, opts ...string) (*Client, error)",
+			},
+		},
+		{
+			name: "wildcard package",
+			sig:  "func(_.Writer, _.Reader) (_, error)",
+			want: []string{
+				"func Copy(dst io.Writer, src io.Reader) (int64, error)",
+			},
+		},
+		{
+			name: "composite types",
+			sig:  "func([]_, string) string",
+			want: []string{
+				"func Join(elems []string, sep string) string",
+			},
+		},
+		{
+			name: "channel direction",
+			sig:  "func(...) <-chan _",
+			want: []string{
+				"func Watch(ctx context.Context) <-chan string",
+			},
+		},
+		{
+			name: "no parameters and no results",
+			sig:  "func() ()",
+			want: []string{
+				"func (Client) Ping()",
+			},
+		},
+		{
+			name: "pointer receiver",
+			sig:  "func (*_) _(...)",
+			want: []string{
+				"func (*Client) Close() error",
+				"func (*Cache[K, V]) Get(key K) (V, bool)",
+			},
+		},
+		{
+			name: "generic receiver and name",
+			sig:  "func (*Cache) G*(_) (_, bool)",
+			want: []string{
+				"func (*Cache[K, V]) Get(key K) (V, bool)",
+			},
+		},
+		{
+			name: "name glob",
+			sig:  "func New*(...)",
+			want: []string{
+				"func NewClient(ctx context.Context) (*Client, error)",
+			},
+		},
+		{
+			name:    "not a func",
+			sig:     "(int) error",
+			wantErr: true,
+		},
+		{
+			name:    "unbalanced",
+			sig:     "func(int",
+			wantErr: true,
+		},
+		{
+			name:    "invalid type",
+			sig:     "func(map[)",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			includePrivate = false
+			skipValues = true
+			maxValueLength = 30
+			sigQuery = tt.sig
+			t.Cleanup(func() {
+				sigQuery = ""
+				filters = nil
+				skipValues = false
+			})
+
+			err := compileFilters()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("compileFilters() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			filename := createTestFile(t, code)
+			output := captureOutput(func() {
+				if err := processFile(filename, token.NewFileSet()); err != nil {
+					t.Fatal(err)
+				}
+			})
+
+			var got []string
+			for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+				got = append(got, strings.TrimPrefix(line, "test.go: "))
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
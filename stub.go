@@ -0,0 +1,360 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"go/types"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	// Output directory of the stub subcommand
+	stubDir string
+
+	// Declarations are buffered for the stub subcommand
+	stubMode bool
+)
+
+// Write a stub of each package: stub [flags] <path...>. Types, constants
+// and variables are kept, functions and methods panic, and init functions
+// are dropped.
+func runStub(paths []string, fset *token.FileSet) error {
+	if len(paths) == 0 {
+		return fmt.Errorf("usage: revbro stub [-o dir] <path...>")
+	}
+
+	// Stubs need every declaration their signatures refer to, and the type
+	// checker to resolve the types of variables and the names of imports.
+	// Flags selecting a subset of the declarations are ignored.
+	stubMode = true
+	includePrivate = true
+	typeCheck = true
+	filters = nil
+	checkDoc, testsMode = false, false
+
+	var err error
+	if loadPackages {
+		err = processPackages(paths, fset)
+	} else {
+		err = processPaths(paths, fset)
+	}
+	if err != nil {
+		return err
+	}
+
+	decls := collectedDecls
+	collectedDecls = nil
+	if err := writeStubs(decls, fset); err != nil {
+		return err
+	}
+	return reportErrors()
+}
+
+// Write one stub file per source file of the declarations, mirroring their
+// path relative to the working directory under the stub directory
+func writeStubs(decls []*Decl, fset *token.FileSet) error {
+	var files []string
+	byFile := make(map[string]map[token.Pos]bool)
+	for _, d := range decls {
+		if byFile[d.File] == nil {
+			files = append(files, d.File)
+			byFile[d.File] = make(map[token.Pos]bool)
+		}
+		byFile[d.File][d.pos] = true
+	}
+
+	for _, file := range files {
+		filename := file
+		if !filepath.IsAbs(filename) {
+			filename = filepath.Join(workDir, file)
+		}
+		f, cp, err := checkFile(filename, fset)
+		if err != nil {
+			// Files with parse errors were reported while listing them
+			if err := keepGoingOn(err); err != nil {
+				return err
+			}
+			continue
+		}
+		src, err := stubFile(f, cp, byFile[file], fset)
+		if err != nil {
+			return fmt.Errorf("error generating stub for %s: %v", file, err)
+		}
+
+		out := filepath.Join(stubDir, stubPath(file))
+		if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(out, src, 0644); err != nil {
+			return err
+		}
+		fmt.Println(out)
+	}
+	return nil
+}
+
+// Helper function to get the path of a stub file relative to the stub
+// directory, dropping the leading .. of files outside the working directory
+func stubPath(file string) string {
+	file = filepath.ToSlash(filepath.Clean(file))
+	for strings.HasPrefix(file, "../") {
+		file = file[len("../"):]
+	}
+	return filepath.FromSlash(strings.TrimPrefix(file, "/"))
+}
+
+// stubImports tracks the imports of a source file needed by its stub
+type stubImports struct {
+	file  *ast.File
+	cp    *checkedPackage
+	names map[string]*ast.ImportSpec // by local name
+	used  map[*ast.ImportSpec]bool
+	extra map[string]string // imports not in the source file: path to name
+}
+
+// Generate the stub of a source file, keeping the declarations listed at
+// the given positions
+func stubFile(f *ast.File, cp *checkedPackage, listed map[token.Pos]bool, fset *token.FileSet) ([]byte, error) {
+	imports := newStubImports(f, cp)
+
+	var nodes []ast.Node
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.GenDecl:
+			gen, err := stubGenDecl(d, listed, imports)
+			if err != nil {
+				return nil, err
+			}
+			if gen != nil {
+				nodes = append(nodes, gen)
+			}
+		case *ast.FuncDecl:
+			// Package initialization does not belong in a stub
+			if !listed[d.Name.Pos()] || (d.Recv == nil && d.Name.Name == "init") {
+				continue
+			}
+			fn := *d
+			fn.Body = &ast.BlockStmt{List: []ast.Stmt{
+				&ast.ExprStmt{X: &ast.CallExpr{
+					Fun:  ast.NewIdent("panic"),
+					Args: []ast.Expr{&ast.BasicLit{Kind: token.STRING, Value: strconv.Quote("stub")}},
+				}},
+			}}
+			nodes = append(nodes, &fn)
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by revbro stub. DO NOT EDIT.\n\npackage %s\n", f.Name.Name)
+	var body bytes.Buffer
+	for _, node := range nodes {
+		imports.use(node)
+		body.WriteString("\n")
+		if err := format.Node(&body, fset, node); err != nil {
+			return nil, err
+		}
+		body.WriteString("\n")
+	}
+	if specs := imports.specs(); len(specs) > 0 {
+		fmt.Fprintf(&buf, "\nimport (\n%s)\n", strings.Join(specs, ""))
+	}
+	buf.Write(body.Bytes())
+	return format.Source(buf.Bytes())
+}
+
+// Keep the listed specs of a declaration. Constant declarations are kept
+// whole so that iota keeps its values, and variables lose their values.
+func stubGenDecl(d *ast.GenDecl, listed map[token.Pos]bool, imports *stubImports) (*ast.GenDecl, error) {
+	isListed := func(spec ast.Spec) bool {
+		switch s := spec.(type) {
+		case *ast.TypeSpec:
+			return listed[s.Name.Pos()]
+		case *ast.ValueSpec:
+			for _, name := range s.Names {
+				if listed[name.Pos()] {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	gen := *d
+	gen.Specs = nil
+	for _, spec := range d.Specs {
+		if !isListed(spec) {
+			continue
+		}
+		if d.Tok == token.CONST {
+			return d, nil
+		}
+		if s, ok := spec.(*ast.ValueSpec); ok && d.Tok == token.VAR {
+			specs, err := imports.stubValueSpec(s, listed)
+			if err != nil {
+				return nil, err
+			}
+			gen.Specs = append(gen.Specs, specs...)
+			continue
+		}
+		gen.Specs = append(gen.Specs, spec)
+	}
+	if len(gen.Specs) == 0 {
+		return nil, nil
+	}
+	if len(gen.Specs) > 1 && !gen.Lparen.IsValid() {
+		gen.Lparen = gen.TokPos
+	}
+	return &gen, nil
+}
+
+// Declare the listed variables of a spec without their values: with their
+// declared type, or the type computed by the type checker. Values are never
+// kept, as they may call stubbed functions during initialization, so
+// variables whose type cannot be computed are an error.
+func (imports *stubImports) stubValueSpec(s *ast.ValueSpec, listed map[token.Pos]bool) ([]ast.Spec, error) {
+	if s.Type != nil {
+		spec := *s
+		spec.Names, spec.Values = nil, nil
+		for _, name := range s.Names {
+			if listed[name.Pos()] {
+				spec.Names = append(spec.Names, name)
+			}
+		}
+		return []ast.Spec{&spec}, nil
+	}
+
+	var specs []ast.Spec
+	for _, name := range s.Names {
+		if !listed[name.Pos()] {
+			continue
+		}
+		obj := imports.cp.object(name)
+		if obj == nil {
+			return nil, fmt.Errorf("cannot compute the type of variable %s", name.Name)
+		}
+		// The type is printed as is, like an identifier
+		typeString := types.TypeString(obj.Type(), imports.qualifier)
+		if strings.Contains(typeString, "invalid type") {
+			return nil, fmt.Errorf("cannot compute the type of variable %s", name.Name)
+		}
+		typ := &ast.Ident{Name: typeString}
+		specs = append(specs, &ast.ValueSpec{Doc: s.Doc, Names: []*ast.Ident{name}, Type: typ, Comment: s.Comment})
+	}
+	return specs, nil
+}
+
+func newStubImports(f *ast.File, cp *checkedPackage) *stubImports {
+	imports := &stubImports{
+		file:  f,
+		cp:    cp,
+		names: make(map[string]*ast.ImportSpec),
+		used:  make(map[*ast.ImportSpec]bool),
+		extra: make(map[string]string),
+	}
+	for _, spec := range f.Imports {
+		imports.names[imports.localName(spec)] = spec
+	}
+	return imports
+}
+
+// Helper function to get the name an import is referred to by in its file
+func (imports *stubImports) localName(spec *ast.ImportSpec) string {
+	if spec.Name != nil {
+		return spec.Name.Name
+	}
+	if imports.cp != nil {
+		if obj, ok := imports.cp.info.Implicits[spec].(*types.PkgName); ok {
+			return obj.Name()
+		}
+	}
+	// Guess the package name from the import path, e.g. yaml for
+	// gopkg.in/yaml.v3 or foo for example.com/foo/v2
+	importPath, _ := strconv.Unquote(spec.Path.Value)
+	name := path.Base(importPath)
+	if len(name) > 1 && name[0] == 'v' && strings.Trim(name[1:], "0123456789") == "" {
+		name = path.Base(path.Dir(importPath))
+	}
+	if i := strings.Index(name, "."); i > 0 {
+		name = name[:i]
+	}
+	return strings.TrimPrefix(name, "go-")
+}
+
+// Qualify the packages of type-checked types by their name in the file,
+// importing the packages the file does not import
+func (imports *stubImports) qualifier(p *types.Package) string {
+	if imports.cp != nil && p == imports.cp.pkg {
+		return ""
+	}
+	for name, spec := range imports.names {
+		if importPath, _ := strconv.Unquote(spec.Path.Value); importPath == p.Path() && name != "_" && name != "." {
+			imports.used[spec] = true
+			return name
+		}
+	}
+	imports.extra[p.Path()] = p.Name()
+	return p.Name()
+}
+
+// Record the imports a node refers to
+func (imports *stubImports) use(node ast.Node) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			if x, ok := n.X.(*ast.Ident); ok {
+				if spec, ok := imports.names[x.Name]; ok {
+					imports.used[spec] = true
+				}
+			}
+		case *ast.Ident:
+			// Identifiers of dot imports
+			if spec, ok := imports.names["."]; ok && imports.cp != nil {
+				if obj := imports.cp.info.Uses[n]; obj != nil && obj.Pkg() != nil && obj.Pkg() != imports.cp.pkg {
+					if importPath, _ := strconv.Unquote(spec.Path.Value); importPath == obj.Pkg().Path() {
+						imports.used[spec] = true
+					}
+				}
+			}
+		}
+		return true
+	})
+}
+
+// List the import specs needed by the stub, sorted by path
+func (imports *stubImports) specs() []string {
+	var specs []string
+	for _, spec := range imports.file.Imports {
+		if !imports.used[spec] {
+			continue
+		}
+		if spec.Name != nil {
+			specs = append(specs, fmt.Sprintf("\t%s %s\n", spec.Name.Name, spec.Path.Value))
+		} else {
+			specs = append(specs, fmt.Sprintf("\t%s\n", spec.Path.Value))
+		}
+	}
+	for importPath, name := range imports.extra {
+		if path.Base(importPath) == name {
+			specs = append(specs, fmt.Sprintf("\t%q\n", importPath))
+		} else {
+			specs = append(specs, fmt.Sprintf("\t%s %q\n", name, importPath))
+		}
+	}
+	sort.Slice(specs, func(i, j int) bool {
+		return importSortKey(specs[i]) < importSortKey(specs[j])
+	})
+	return specs
+}
+
+// Helper function to sort import specs by path
+func importSortKey(spec string) string {
+	return spec[strings.Index(spec, `"`):]
+}
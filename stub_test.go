@@ -0,0 +1,241 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStub(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"client/client.go": `package client
+
+import (
+	"context"
+	"io"
+	str "strings"
+	"time"
+)
+
+// Client talks to the server.
+type Client struct {
+	Body    io.Reader
+	Timeout time.Duration
+	name    string
+}
+
+type Level int
+
+const (
+	Debug Level = iota
+	Info
+)
+
+var (
+	DefaultTimeout = 5 * time.Second
+	Default        = New("default")
+	replacer       = str.NewReplacer("a", "b")
+)
+
+// New creates a client.
+func New(name string) *Client {
+	return &Client{name: str.ToUpper(name), Timeout: DefaultTimeout}
+}
+
+func (c *Client) Read(ctx context.Context, p []byte) (n int, err error) {
+	return c.Body.Read(p)
+}
+
+func init() {
+	DefaultTimeout = 10 * time.Second
+}
+`,
+		"client/util.go": `package client
+
+import "fmt"
+
+func describe(c *Client) string { return fmt.Sprint(c.name) }
+`,
+	}
+	writeTestFiles(t, tmpDir, files)
+
+	skipValues = false
+	fileExtensions = ".go"
+	excludeSuffixes = "_test.go"
+	workDir = tmpDir
+	stubDir = filepath.Join(tmpDir, "out")
+	t.Cleanup(func() {
+		stubMode, includePrivate, typeCheck = false, false, false
+		stubDir, workDir = "_stub", ""
+		matchPattern, filters = "", nil
+	})
+
+	// Filters do not apply to stubs, which need every declaration
+	matchPattern = "New"
+	if err := compileFilters(); err != nil {
+		t.Fatal(err)
+	}
+
+	captureOutput(func() {
+		if err := runStub([]string{filepath.Join(tmpDir, "client")}, token.NewFileSet()); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	data, err := os.ReadFile(filepath.Join(stubDir, "client", "client.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stub := string(data)
+	for _, want := range []string{
+		"// Code generated by revbro stub. DO NOT EDIT.",
+		"\t\"context\"\n\t\"io\"\n\tstr \"strings\"\n\t\"time\"\n",
+		"type Client struct {",
+		"\tDebug Level = iota\n\tInfo\n",
+		"\tDefaultTimeout time.Duration\n",
+		"\tDefault        *Client\n",
+		"\treplacer       *str.Replacer\n",
+		"// New creates a client.\nfunc New(name string) *Client { panic(\"stub\") }",
+		"func (c *Client) Read(ctx context.Context, p []byte) (n int, err error) { panic(\"stub\") }",
+	} {
+		if !strings.Contains(stub, want) {
+			t.Errorf("stub does not contain %q:\n%s", want, stub)
+		}
+	}
+
+	data, err = os.ReadFile(filepath.Join(stubDir, "client", "util.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "import") {
+		t.Errorf("unused imports in stub:\n%s", data)
+	}
+
+	// The stub package must compile
+	fset := token.NewFileSet()
+	var parsed []*ast.File
+	for _, name := range []string{"client.go", "util.go"} {
+		f, err := parser.ParseFile(fset, filepath.Join(stubDir, "client", name), nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed = append(parsed, f)
+	}
+	checkConf := conf
+	var typeErrs []string
+	checkConf.Error = func(err error) { typeErrs = append(typeErrs, err.Error()) }
+	checkConf.Check("client", fset, parsed, nil)
+	if len(typeErrs) > 0 {
+		t.Errorf("stub does not compile:\n%s", strings.Join(typeErrs, "\n"))
+	}
+	if strings.Contains(stub, "init") {
+		t.Errorf("stub contains init:\n%s", stub)
+	}
+
+	// A program importing the stub package must build and run
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not available")
+	}
+	writeTestFiles(t, stubDir, map[string]string{
+		"go.mod": "module stub\n\ngo 1.21\n",
+		"main.go": `package main
+
+import "stub/client"
+
+func main() { _ = client.Info }
+`,
+	})
+	cmd := exec.Command("go", "run", ".")
+	cmd.Dir = stubDir
+	cmd.Env = append(os.Environ(), "GOWORK=off", "GOFLAGS=-mod=mod")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("importing the stub failed: %v\n%s", err, out)
+	}
+}
+
+func TestStubModule(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not available")
+	}
+	tmpDir := t.TempDir()
+	writeTestFiles(t, tmpDir, map[string]string{
+		"go.mod": "module example.com/m\n\ngo 1.21\n",
+		"a/a.go": `package a
+
+import "example.com/m/sub"
+
+var X = sub.New()
+
+var Name = X.Name()
+`,
+		"sub/sub.go": `package sub
+
+type Server struct{ name string }
+
+func New() *Server { return &Server{name: "x"} }
+
+func (s *Server) Name() string { return s.name }
+`,
+		"bad/bad.go": `package bad
+
+var Z = undefined()
+`,
+	})
+
+	skipValues = false
+	fileExtensions = ".go"
+	excludeSuffixes = "_test.go"
+	workDir = tmpDir
+	stubDir = filepath.Join(tmpDir, "out")
+	t.Cleanup(func() {
+		stubMode, includePrivate, typeCheck = false, false, false
+		stubDir, workDir = "_stub", ""
+	})
+
+	captureOutput(func() {
+		if err := runStub([]string{filepath.Join(tmpDir, "a"), filepath.Join(tmpDir, "sub")}, token.NewFileSet()); err != nil {
+			t.Fatal(err)
+		}
+	})
+	data, err := os.ReadFile(filepath.Join(stubDir, "a", "a.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"var X *sub.Server\n", "var Name string\n"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("stub does not contain %q:\n%s", want, data)
+		}
+	}
+
+	// A program importing the stubs must not call stubbed functions while
+	// initializing them
+	writeTestFiles(t, stubDir, map[string]string{
+		"go.mod": "module example.com/m\n\ngo 1.21\n",
+		"cmd/run/main.go": `package main
+
+import "example.com/m/a"
+
+func main() { _ = a.X }
+`,
+	})
+	cmd := exec.Command("go", "run", "./cmd/run")
+	cmd.Dir = stubDir
+	cmd.Env = append(os.Environ(), "GOWORK=off", "GOFLAGS=-mod=mod")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("importing the stub failed: %v\n%s", err, out)
+	}
+
+	// Variables of unknown types cannot be stubbed
+	captureOutput(func() {
+		err = runStub([]string{filepath.Join(tmpDir, "bad")}, token.NewFileSet())
+	})
+	if err == nil || !strings.Contains(err.Error(), "variable Z") {
+		t.Errorf("got error %v, want an error about variable Z", err)
+	}
+}
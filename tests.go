@@ -0,0 +1,266 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Kinds of test entry points
+const (
+	testKindTest      = "test"
+	testKindBenchmark = "benchmark"
+	testKindFuzz      = "fuzz"
+	testKindExample   = "example"
+	testKindMain      = "main"
+)
+
+var (
+	testsMode bool
+
+	// Identifiers referenced by test files, by directory
+	testRefs   = make(map[string]map[string]bool)
+	testRefsMu sync.Mutex
+
+	// Exported declarations of non-test files, checked for tests at the end
+	testCandidates []*Decl
+)
+
+// Helper function to check if a file is a test file
+func isTestFile(filename string) bool {
+	name := strings.TrimSuffix(filename, filepath.Ext(filename))
+	return strings.HasSuffix(name, "_test")
+}
+
+// Keep the test entry points of a test file, and record the identifiers it
+// references. The test package is type-checked to resolve method calls.
+func testFileDecls(f *ast.File, filename string, fset *token.FileSet, decls []*Decl) []*Decl {
+	dir := filepath.Dir(relativePath(filename))
+	var refs map[string]bool
+	if checked, cp, err := checkFile(filename, fset); err == nil && cp != nil {
+		refs = testFileRefs(checked, cp)
+	} else {
+		refs = testFileRefs(f, nil)
+	}
+
+	var entries []*Decl
+	for _, d := range decls {
+		if d.funcDecl == nil || d.funcDecl.Recv != nil {
+			continue
+		}
+		d.Test = testEntryKind(d.funcDecl)
+		if d.Test == "" {
+			continue
+		}
+		if d.Test == testKindExample {
+			d.Example = exampleTarget(d.Name)
+			// The documented identifier, and the type of a documented
+			// method, are referenced by its example
+			refs[d.Example] = true
+			if typeName, _, ok := strings.Cut(d.Example, "."); ok {
+				refs[typeName] = true
+			}
+		}
+		entries = append(entries, d)
+	}
+
+	testRefsMu.Lock()
+	defer testRefsMu.Unlock()
+	if testRefs[dir] == nil {
+		testRefs[dir] = make(map[string]bool)
+	}
+	for name := range refs {
+		testRefs[dir][name] = true
+	}
+	return entries
+}
+
+// Collect the names of the package under test referenced by a test file:
+// unqualified identifiers, identifiers qualified by the package name in
+// external _test packages, and Type.Method for methods selected on a type
+// (e.g. method expressions) or, with type information, on a value. Methods
+// that cannot be resolved are recorded as .Method, referencing the methods
+// of that name of all types.
+func testFileRefs(f *ast.File, cp *checkedPackage) map[string]bool {
+	pkgName, _ := strings.CutSuffix(f.Name.Name, "_test")
+	if pkgName == f.Name.Name {
+		pkgName = ""
+	}
+
+	refs := make(map[string]bool)
+	qualified := make(map[*ast.Ident]bool)
+	for _, decl := range f.Decls {
+		ast.Inspect(decl, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.ImportSpec:
+				return false
+			case *ast.SelectorExpr:
+				qualified[n.Sel] = true
+				if x, ok := n.X.(*ast.Ident); ok && pkgName != "" && x.Name == pkgName {
+					refs[n.Sel.Name] = true
+					return false
+				}
+				if typeName := refTypeName(n.X, pkgName); typeName != "" {
+					refs[typeName+"."+n.Sel.Name] = true
+				}
+				if cp == nil {
+					refs["."+n.Sel.Name] = true
+				} else if fn, ok := cp.info.Uses[n.Sel].(*types.Func); ok {
+					if recv := receiverTypeName(fn); recv != "" {
+						refs[recv+"."+fn.Name()] = true
+					} else {
+						refs["."+fn.Name()] = true
+					}
+				} else if cp.info.Uses[n.Sel] == nil {
+					refs["."+n.Sel.Name] = true
+				}
+			case *ast.Ident:
+				if !qualified[n] {
+					refs[n.Name] = true
+				}
+			}
+			return true
+		})
+	}
+	return refs
+}
+
+// Helper function to get the name of the defined type of a method's
+// receiver. Interface methods and functions have none.
+func receiverTypeName(fn *types.Func) string {
+	recv := fn.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return ""
+	}
+	t := recv.Type()
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, ok := t.(*types.Named)
+	if !ok || types.IsInterface(named) {
+		return ""
+	}
+	return named.Obj().Name()
+}
+
+// Helper function to get the name of the type an expression may refer to:
+// Foo, *Foo or (*Foo), qualified by the package name in _test packages
+func refTypeName(x ast.Expr, pkgName string) string {
+	switch x := x.(type) {
+	case *ast.ParenExpr:
+		return refTypeName(x.X, pkgName)
+	case *ast.StarExpr:
+		return refTypeName(x.X, pkgName)
+	case *ast.Ident:
+		return x.Name
+	case *ast.SelectorExpr:
+		if id, ok := x.X.(*ast.Ident); ok && pkgName != "" && id.Name == pkgName {
+			return x.Sel.Name
+		}
+	}
+	return ""
+}
+
+// Classify a test entry point by its name and signature, as go test does
+func testEntryKind(decl *ast.FuncDecl) string {
+	name := decl.Name.Name
+	params := fieldTypes(decl.Type.Params)
+	hasResults := decl.Type.Results != nil && len(decl.Type.Results.List) > 0
+
+	takes := func(typ string) bool {
+		if len(params) != 1 || hasResults {
+			return false
+		}
+		star, ok := params[0].(*ast.StarExpr)
+		if !ok {
+			return false
+		}
+		sel, ok := star.X.(*ast.SelectorExpr)
+		return ok && sel.Sel.Name == typ
+	}
+
+	switch {
+	case name == "TestMain" && takes("M"):
+		return testKindMain
+	case isTestName(name, "Test") && takes("T"):
+		return testKindTest
+	case isTestName(name, "Benchmark") && takes("B"):
+		return testKindBenchmark
+	case isTestName(name, "Fuzz") && takes("F"):
+		return testKindFuzz
+	case isTestName(name, "Example") && len(params) == 0 && !hasResults:
+		return testKindExample
+	}
+	return ""
+}
+
+// Helper function to check if a name is prefix followed by nothing or by
+// a character that is not a lower-case letter (TestFoo, not Testfoo)
+func isTestName(name, prefix string) bool {
+	if !strings.HasPrefix(name, prefix) {
+		return false
+	}
+	if len(name) == len(prefix) {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(name[len(prefix):])
+	return !unicode.IsLower(r)
+}
+
+// Get the identifier documented by an example: ExampleFoo documents Foo,
+// ExampleFoo_Bar documents the method Foo.Bar, and a lower-case suffix
+// (ExampleFoo_second) distinguishes several examples. Package examples
+// return an empty string.
+func exampleTarget(name string) string {
+	parts := strings.Split(strings.TrimPrefix(name, "Example"), "_")
+	if n := len(parts); n > 1 && parts[n-1] != "" && unicode.IsLower([]rune(parts[n-1])[0]) {
+		parts = parts[:n-1]
+	}
+	if len(parts) > 2 {
+		parts = parts[:2]
+	}
+	return strings.Join(parts, ".")
+}
+
+// Set aside the declarations of non-test files until all test files have
+// been seen, and return the test entry points
+func splitTestDecls(decls []*Decl) []*Decl {
+	var entries []*Decl
+	for _, d := range decls {
+		if d.Test != "" {
+			entries = append(entries, d)
+		} else if d.Exported {
+			testCandidates = append(testCandidates, d)
+		}
+	}
+	return entries
+}
+
+// Get the exported declarations that no test file of their directory
+// references, in file order. Methods are matched as Type.Method, or as
+// .Method when calls could not be resolved.
+func untestedDecls() []*Decl {
+	testRefsMu.Lock()
+	defer testRefsMu.Unlock()
+
+	var untested []*Decl
+	for _, d := range testCandidates {
+		refs := testRefs[filepath.Dir(d.File)]
+		if !refs[qualifiedName(d)] && !(d.Kind == KindMethod && refs["."+d.Name]) {
+			d.Untested = true
+			untested = append(untested, d)
+		}
+	}
+	testCandidates = nil
+	testRefs = make(map[string]map[string]bool)
+	sort.SliceStable(untested, func(i, j int) bool {
+		return untested[i].File < untested[j].File
+	})
+	return untested
+}
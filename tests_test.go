@@ -0,0 +1,199 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func TestTestsMode(t *testing.T) {
+	files := map[string]string{
+		"go.mod": "module example.com/m\n\ngo 1.21\n",
+		"client.go": `package m
+
+type Client struct{}
+
+func NewClient() *Client { return &Client{} }
+
+func (c *Client) Do() error { return nil }
+
+func (c *Client) Close() error { return nil }
+
+func (c *Client) Parse() error { return nil }
+
+func Parse(s string) int { return 0 }
+
+func Unused() {}
+
+func Reset() {}
+
+const Version = "1"
+`,
+		"client_test.go": `package m
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	if Parse("") != 0 {
+		t.Fatal()
+	}
+}
+
+func Testhelper(t *testing.T) {}
+
+func BenchmarkDo(b *testing.B) {
+	c := NewClient()
+	for i := 0; i < b.N; i++ {
+		c.Do()
+	}
+}
+
+func FuzzParse(f *testing.F) {}
+
+func TestMain(m *testing.M) {}
+
+func Helper() {}
+`,
+		"example_test.go": `package m_test
+
+import (
+	"fmt"
+
+	"example.com/m"
+)
+
+func Example() {
+	m.Reset()
+}
+
+func ExampleClient_Close() {}
+
+func ExampleClient_Close_twice() {}
+
+func ExampleVersion_second() {
+	fmt.Println()
+}
+`,
+	}
+
+	tmpDir := t.TempDir()
+	writeTestFiles(t, tmpDir, files)
+
+	tests := []struct {
+		name     string
+		packages bool
+	}{
+		{name: "files"},
+		{name: "packages", packages: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workDir = tmpDir
+			includePrivate = false
+			skipValues = true
+			maxValueLength = 30
+			fileExtensions = ".go"
+			excludeSuffixes = "_test.go"
+			testsMode, loadPackages = true, tt.packages
+			t.Cleanup(func() {
+				testsMode, loadPackages = false, false
+				skipValues, workDir = false, ""
+			})
+
+			output := captureOutput(func() {
+				var err error
+				if tt.packages {
+					err = processPackages([]string{"./..."}, token.NewFileSet())
+				} else {
+					err = processPaths([]string{tmpDir}, token.NewFileSet())
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := flushDecls(); err != nil {
+					t.Fatal(err)
+				}
+			})
+
+			want := []string{
+				"client_test.go: func TestParse(t *testing.T) // test",
+				"client_test.go: func BenchmarkDo(b *testing.B) // benchmark",
+				"client_test.go: func FuzzParse(f *testing.F) // fuzz",
+				"client_test.go: func TestMain(m *testing.M) // main",
+				"example_test.go: func Example() // example",
+				"example_test.go: func ExampleClient_Close() // example: Client.Close",
+				"example_test.go: func ExampleClient_Close_twice() // example: Client.Close",
+				"example_test.go: func ExampleVersion_second() // example: Version",
+				// Methods are matched as Type.Method: c.Do() references
+				// Client.Do, but a function of the same name does not
+				"client.go: func (*Client) Parse() error // untested",
+				"client.go: func Unused() // untested",
+			}
+			if tt.packages {
+				// External test files belong to the _test package
+				for i, line := range want {
+					file, rest, _ := strings.Cut(line, ": ")
+					pkg := "example.com/m"
+					if file == "example_test.go" {
+						pkg += "_test"
+					}
+					want[i] = pkg + ": " + rest
+				}
+			}
+			if got := strings.TrimSpace(output); got != strings.Join(want, "\n") {
+				t.Errorf("got:\n%s\nwant:\n%s", got, strings.Join(want, "\n"))
+			}
+		})
+	}
+}
+
+func TestExampleTarget(t *testing.T) {
+	tests := map[string]string{
+		"Example":               "",
+		"Example_second":        "",
+		"ExampleFoo":            "Foo",
+		"ExampleFoo_second":     "Foo",
+		"ExampleFoo_Bar":        "Foo.Bar",
+		"ExampleFoo_Bar_second": "Foo.Bar",
+	}
+	for name, want := range tests {
+		if got := exampleTarget(name); got != want {
+			t.Errorf("exampleTarget(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestTestFileRefs(t *testing.T) {
+	f, err := parser.ParseFile(token.NewFileSet(), "m_test.go", `package m_test
+
+import (
+	"testing"
+
+	"example.com/m"
+)
+
+func TestClient(t *testing.T) {
+	c := m.NewClient()
+	c.Do()
+	close := (*m.Client).Close
+	close(c)
+	t.Log(m.Version)
+}
+`, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refs := testFileRefs(f, nil)
+	// Without type information, c.Do() references the methods named Do
+	for _, name := range []string{"NewClient", "Client", "Client.Close", "Version", ".Do"} {
+		if !refs[name] {
+			t.Errorf("%s is not referenced", name)
+		}
+	}
+	for _, name := range []string{"Do", "Close", "Log", "T"} {
+		if refs[name] {
+			t.Errorf("%s is referenced", name)
+		}
+	}
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// checkedPackage holds the files of a package directory parsed and
+// type-checked together
+type checkedPackage struct {
+	pkg  *types.Package
+	info *types.Info
+}
+
+// parsedDir holds the parsed files of a directory, by absolute filename
+type parsedDir struct {
+	files map[string]*ast.File
+	errs  map[string]error
+	pkgs  map[string]*checkedPackage // by package name
+}
+
+var (
+	// Cache of parsed and type-checked directories
+	parsedDirs = make(map[string]*parsedDir)
+
+	// Serializes type checking: the cache and the importer are not safe for
+	// concurrent use
+	typeCheckMu sync.Mutex
+)
+
+// Parse the file and type-check it with the other files of its directory
+// that belong to the same package. Directories are only processed once.
+// A partially parsed file is returned along with its parse error.
+func checkFile(filename string, fset *token.FileSet) (*ast.File, *checkedPackage, error) {
+	typeCheckMu.Lock()
+	defer typeCheckMu.Unlock()
+
+	absPath, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	dir := parseDir(filepath.Dir(absPath), fset)
+
+	f, ok := dir.files[absPath]
+	if !ok {
+		// Not selected by the directory filters (e.g. an explicitly named file)
+		f, err = parser.ParseFile(fset, absPath, nil, parser.ParseComments)
+		if f == nil {
+			return nil, nil, err
+		}
+		dir.files[absPath] = f
+		if err != nil {
+			dir.errs[absPath] = err
+		}
+	}
+
+	// Files with parse errors are returned as parsed, but not type-checked
+	if err := dir.errs[absPath]; err != nil {
+		return f, nil, err
+	}
+
+	pkgName := f.Name.Name
+	if cp, ok := dir.pkgs[pkgName]; ok {
+		return f, cp, nil
+	}
+
+	// Collect the files of the same package, in a stable order
+	var filenames []string
+	for name, file := range dir.files {
+		if file.Name.Name == pkgName && dir.errs[name] == nil {
+			filenames = append(filenames, name)
+		}
+	}
+	sort.Strings(filenames)
+	files := make([]*ast.File, 0, len(filenames))
+	for _, name := range filenames {
+		files = append(files, dir.files[name])
+	}
+
+	cp := &checkedPackage{
+		info: &types.Info{
+			Defs:      make(map[*ast.Ident]types.Object),
+			Uses:      make(map[*ast.Ident]types.Object),
+			Implicits: make(map[ast.Node]types.Object),
+		},
+	}
+	// Errors are silenced by conf; partial information is still useful
+	cp.pkg, _ = conf.Check(pkgName, fset, files, cp.info)
+	dir.pkgs[pkgName] = cp
+	return f, cp, nil
+}
+
+// Parse all files of a directory matching the extension and exclude filters
+func parseDir(path string, fset *token.FileSet) *parsedDir {
+	if dir, ok := parsedDirs[path]; ok {
+		return dir
+	}
+	dir := &parsedDir{
+		files: make(map[string]*ast.File),
+		errs:  make(map[string]error),
+		pkgs:  make(map[string]*checkedPackage),
+	}
+	parsedDirs[path] = dir
+
+	entries, _ := os.ReadDir(path)
+	extensions := splitExtensions()
+	excludes := splitExcludes()
+	for _, entry := range entries {
+		name := filepath.Join(path, entry.Name())
+		if entry.IsDir() || isExcluded(name, excludes) || !hasExtension(name, extensions) || !fileMatchesBuild(name) {
+			continue
+		}
+		f, err := parser.ParseFile(fset, name, nil, parser.ParseComments)
+		if f == nil {
+			continue
+		}
+		dir.files[name] = f
+		if err != nil {
+			dir.errs[name] = err
+		}
+	}
+	return dir
+}
+
+// sourceImporter imports packages from their export data, and packages
+// without export data, e.g. of the same module or of third-party modules,
+// from their source. Packages imported from source import their own
+// dependencies through it, so that types shared with the export data (e.g.
+// io.Reader) stay identical. It is not safe for concurrent use.
+type sourceImporter struct {
+	gc       types.ImporterFrom
+	fset     *token.FileSet
+	pkgs     map[string]*types.Package // by import path, then directory
+	checking map[string]bool
+}
+
+func newSourceImporter() *sourceImporter {
+	return &sourceImporter{
+		gc:       importer.Default().(types.ImporterFrom),
+		fset:     token.NewFileSet(),
+		pkgs:     make(map[string]*types.Package),
+		checking: make(map[string]bool),
+	}
+}
+
+func (imp *sourceImporter) Import(path string) (*types.Package, error) {
+	return imp.ImportFrom(path, workDir, 0)
+}
+
+func (imp *sourceImporter) ImportFrom(path, dir string, mode types.ImportMode) (*types.Package, error) {
+	// The same import path may resolve to different packages in different
+	// modules
+	key := path + "\x00" + dir
+	if pkg, ok := imp.pkgs[key]; ok {
+		return pkg, nil
+	}
+	if pkg, ok := imp.pkgs[path]; ok {
+		return pkg, nil
+	}
+	if pkg, err := imp.gc.ImportFrom(path, dir, mode); err == nil {
+		imp.pkgs[path] = pkg
+		return pkg, nil
+	}
+
+	// Find the files of the package like the go command, from the module
+	// of the importing directory, for the selected platform and tags
+	ctxt := buildContext()
+	ctxt.Dir = dir
+	bp, err := ctxt.Import(path, dir, 0)
+	if err != nil {
+		return nil, err
+	}
+	if pkg, ok := imp.pkgs[bp.Dir]; ok {
+		imp.pkgs[key] = pkg
+		return pkg, nil
+	}
+
+	if imp.checking[bp.Dir] {
+		return nil, fmt.Errorf("import cycle through %s", path)
+	}
+	imp.checking[bp.Dir] = true
+	defer delete(imp.checking, bp.Dir)
+	var files []*ast.File
+	for _, name := range append(bp.GoFiles, bp.CgoFiles...) {
+		f, err := parser.ParseFile(imp.fset, filepath.Join(bp.Dir, name), nil, 0)
+		if f == nil || err != nil {
+			continue
+		}
+		files = append(files, f)
+	}
+	check := types.Config{Importer: imp, Error: conf.Error, FakeImportC: true}
+	pkg, _ := check.Check(bp.ImportPath, imp.fset, files, nil)
+	imp.pkgs[key], imp.pkgs[bp.Dir] = pkg, pkg
+	return pkg, nil
+}
+
+// Lookup the type-checked object defined by an identifier
+func (cp *checkedPackage) object(ident *ast.Ident) types.Object {
+	if cp == nil {
+		return nil
+	}
+	obj := cp.info.Defs[ident]
+	if obj == nil || obj.Type() == nil || obj.Type() == types.Typ[types.Invalid] {
+		return nil
+	}
+	return obj
+}
+
+// Format a type as it would be written in the package, qualifying other
+// packages by their name
+func (cp *checkedPackage) typeString(t types.Type) string {
+	return types.TypeString(t, func(p *types.Package) string {
+		if p == cp.pkg {
+			return ""
+		}
+		return p.Name()
+	})
+}
+
+// Replace the syntactic type of a ValueSpec entry with the type-checked one,
+// and the value of iota-based constants with the computed one
+func (cp *checkedPackage) resolveValueSpecEntry(entry *valueSpecEntry, spec *ast.ValueSpec, i int) {
+	obj := cp.object(entry.name)
+	if obj == nil {
+		return
+	}
+	entry.typ = cp.typeString(obj.Type())
+
+	c, ok := obj.(*types.Const)
+	if !ok || skipValues {
+		return
+	}
+	if len(spec.Values) == 0 || (i < len(spec.Values) && usesIota(spec.Values[i])) {
+		if val := constValueString(c); val != "" {
+			entry.value = val
+		}
+	}
+}
+
+// Get the underlying type of a defined type whose declaration refers to
+// another named type, e.g. float64 for "type Celsius Temperature"
+func (cp *checkedPackage) underlying(spec *ast.TypeSpec) string {
+	if spec.Assign.IsValid() {
+		return ""
+	}
+	switch spec.Type.(type) {
+	case *ast.Ident, *ast.SelectorExpr, *ast.IndexExpr, *ast.IndexListExpr, *ast.ParenExpr:
+	default:
+		return ""
+	}
+	obj := cp.object(spec.Name)
+	if obj == nil {
+		return ""
+	}
+	under := obj.Type().Underlying()
+	if under == types.Typ[types.Invalid] {
+		return ""
+	}
+	if str := cp.typeString(under); str != types.ExprString(spec.Type) {
+		return str
+	}
+	return ""
+}
+
+// Format a computed constant value
+func constValueString(c *types.Const) string {
+	if c.Val().Kind() == constant.Unknown {
+		return ""
+	}
+	return c.Val().ExactString()
+}
+
+// Helper function to check if an expression refers to iota
+func usesIota(expr ast.Expr) bool {
+	found := false
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Ident); ok && ident.Name == "iota" {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
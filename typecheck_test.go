@@ -0,0 +1,133 @@
+package main
+
+import (
+	"go/token"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTypeCheck(t *testing.T) {
+	includePrivate = true
+	skipValues = false
+	maxValueLength = 30
+	fileExtensions = ".go"
+	excludeSuffixes = "_test.go"
+	typeCheck = true
+	t.Cleanup(func() { typeCheck, includePrivate = false, false })
+
+	filename := createTestFile(t, `package test
+
+import "time"
+
+type Temperature float64
+type Celsius Temperature
+
+type Weekday int
+
+const (
+	Sunday Weekday = iota
+	Monday
+	Tuesday
+)
+
+const (
+	KB = 1 << (10 * (iota + 1))
+	MB
+)
+
+const Timeout = 30 * time.Second
+
+var Default = NewThing()
+var names = []string{"a"}
+
+type Thing struct{}
+
+func NewThing() *Thing { return nil }
+`)
+
+	output := captureOutput(func() {
+		if err := processFile(filename, token.NewFileSet()); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	want := []string{
+		"test.go: type Temperature float64",
+		"test.go: type Celsius Temperature // underlying: float64",
+		"test.go: type Weekday int // enum without String()",
+		"test.go: const Sunday Weekday = 0",
+		"test.go: const Monday Weekday = 1",
+		"test.go: const Tuesday Weekday = 2",
+		"test.go: const KB = 1024",
+		"test.go: const MB = 1048576",
+		"test.go: const Timeout time.Duration = 30 * time.Second",
+		"test.go: var Default *Thing = NewThing()",
+		"test.go: var names []string = []string{…}",
+		"test.go: type Thing struct { }",
+		"test.go: func NewThing() *Thing",
+	}
+	got := strings.Split(strings.TrimSpace(output), "\n")
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	decls, err := extractDecls(filename, nil, token.NewFileSet())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kbType string
+	for _, d := range decls {
+		if d.Name == "KB" {
+			kbType = d.Type
+		}
+	}
+	if kbType != "untyped int" {
+		t.Errorf("KB has type %q, want %q", kbType, "untyped int")
+	}
+}
+
+func TestTypeCheckModule(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTestFiles(t, tmpDir, map[string]string{
+		"go.mod": "module example.com/m\n\ngo 1.21\n",
+		"a/a.go": `package a
+
+import "example.com/m/sub"
+
+var X = sub.New()
+
+var Y = sub.Default
+`,
+		"sub/sub.go": `package sub
+
+import "io"
+
+type Server struct{ Body io.Reader }
+
+var Default = New()
+
+func New() *Server { return nil }
+`,
+	})
+
+	includePrivate = false
+	skipValues = false
+	maxValueLength = 30
+	fileExtensions = ".go"
+	excludeSuffixes = "_test.go"
+	workDir = tmpDir
+	typeCheck = true
+	t.Cleanup(func() { typeCheck, workDir = false, "" })
+
+	output := captureOutput(func() {
+		if err := processPath(filepath.Join(tmpDir, "a"), token.NewFileSet()); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	want := "a/a.go: var X *sub.Server = sub.New()\na/a.go: var Y *sub.Server = sub.Default"
+	if got := strings.TrimSpace(output); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}